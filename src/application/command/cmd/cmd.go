@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"github.com/go-clarum/agent/application/command/cmd/internal"
+	"github.com/go-clarum/agent/application/command/common"
+	"github.com/go-clarum/agent/application/session"
+	"github.com/go-clarum/agent/infrastructure/logging"
+)
+
+type handler struct {
+	endpoints *common.EndpointRegistry[*internal.Endpoint]
+	logger    *logging.Logger
+}
+
+func NewCommandHandler() common.CommandHandler {
+	return &handler{
+		endpoints: common.NewEndpointRegistry[*internal.Endpoint](),
+		logger:    logging.NewLogger("CommandHandler"),
+	}
+}
+
+func (h *handler) HandledCommands() []any {
+	return []any{
+		&commands.InitEndpointCommand{},
+		&commands.ShutdownEndpointCommand{},
+		&commands.GetOutputCommand{},
+		&commands.AssertRunningCommand{},
+		&commands.AssertExitedCommand{},
+	}
+}
+
+func (h *handler) Handle(session *session.Session, command any) any {
+	switch c := command.(type) {
+	case *commands.InitEndpointCommand:
+		return &commands.InitEndpointResult{Error: h.InitializeEndpoint(session, c)}
+	case *commands.ShutdownEndpointCommand:
+		return &commands.ShutdownEndpointResult{Error: h.ShutdownEndpoint(session.Id, c)}
+	case *commands.GetOutputCommand:
+		lines, err := h.GetOutput(session.Id, c)
+		return &commands.GetOutputResult{Lines: lines, Error: err}
+	case *commands.AssertRunningCommand:
+		return &commands.AssertRunningResult{Error: h.AssertRunning(session.Id, c)}
+	case *commands.AssertExitedCommand:
+		exitCode, err := h.AssertExited(session.Id, c)
+		return &commands.AssertExitedResult{ExitCode: exitCode, Error: err}
+	default:
+		h.logger.Errorf("unsupported command [%T]", command)
+		return nil
+	}
+}
+
+func (h *handler) CloseSession(sessionId string) {
+	for _, endpoint := range h.endpoints.RemoveSession(sessionId) {
+		h.logger.Infof("shutting down command endpoint [%s]", endpoint.Name)
+		if err := endpoint.Shutdown(); err != nil {
+			h.logger.Errorf("error during command endpoint shutdown - %s", err)
+		}
+	}
+}
+
+// InitializeEndpoint starts the process of a new command endpoint.
+// An existing endpoint with the same name is shut down first, since both would most likely compete for the same resources.
+func (h *handler) InitializeEndpoint(session *session.Session, ic *commands.InitEndpointCommand) error {
+	newEndpoint, err := internal.NewEndpoint(ic, session.Publish)
+	if err != nil {
+		return h.handleError("failed to initialize command endpoint - %s", err)
+	}
+
+	if oldEndpoint, exists := h.endpoints.Remove(session.Id, newEndpoint.Name); exists {
+		h.logger.Infof("command endpoint [%s] already exists - replacing", oldEndpoint.Name)
+		if err := oldEndpoint.Shutdown(); err != nil {
+			h.logger.Errorf("error during command endpoint shutdown - %s", err)
+		}
+	}
+
+	if err := newEndpoint.Start(); err != nil {
+		return h.handleError("failed to initialize command endpoint - %s", err)
+	}
+
+	h.endpoints.Put(session.Id, newEndpoint.Name, newEndpoint)
+	logging.Infof("registered command endpoint [%s]", newEndpoint.Name)
+
+	return nil
+}
+
+func (h *handler) ShutdownEndpoint(sessionId string, sc *commands.ShutdownEndpointCommand) error {
+	endpoint, exists := h.endpoints.Remove(sessionId, sc.Name)
+	if !exists {
+		return h.handleError("unable to shutdown command endpoint - endpoint [%s] does not exist", sc.Name)
+	}
+
+	h.logger.Infof("shutting down command endpoint [%s]", endpoint.Name)
+	if err := endpoint.Shutdown(); err != nil {
+		return h.handleError("error during command endpoint shutdown - %s", err)
+	}
+
+	return nil
+}
+
+// GetOutput returns the buffered output of the process, also after it has exited, until the endpoint is shut down.
+func (h *handler) GetOutput(sessionId string, gc *commands.GetOutputCommand) ([]*commands.OutputLine, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, gc.Name)
+	if !exists {
+		return nil, h.handleError("unable to get output - command endpoint [%s] does not exist", gc.Name)
+	}
+
+	return endpoint.Output(gc.Tail), nil
+}
+
+func (h *handler) AssertRunning(sessionId string, ac *commands.AssertRunningCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, ac.Name)
+	if !exists {
+		return h.handleError("command endpoint [%s] not found - assert running action will not be executed", ac.Name)
+	}
+
+	return endpoint.AssertRunning()
+}
+
+func (h *handler) AssertExited(sessionId string, ac *commands.AssertExitedCommand) (int, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, ac.Name)
+	if !exists {
+		return 0, h.handleError("command endpoint [%s] not found - assert exited action will not be executed", ac.Name)
+	}
+
+	return endpoint.AssertExited(ac.ExitCode, ac.Timeout)
+}
+
+func (h *handler) handleError(format string, a ...any) error {
+	errorMessage := fmt.Sprintf(format, a...)
+	h.logger.Errorf(errorMessage)
+	return errors.New(errorMessage)
+}
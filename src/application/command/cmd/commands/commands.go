@@ -0,0 +1,144 @@
+package commands
+
+import "time"
+
+type InitEndpointCommand struct {
+	Name            string
+	CmdComponents   []string
+	Warmup          time.Duration
+	ReadinessProbes []*ReadinessProbe
+	// StreamOutput publishes every output line of the process as OutputEvent to the session
+	StreamOutput bool
+	// OutputBufferLines is the number of most recent output lines kept for GetOutputCommand
+	OutputBufferLines int
+	// Env is merged into the environment of the agent, or replaces it completely if ReplaceEnv is set
+	Env        map[string]string
+	ReplaceEnv bool
+	// WorkingDir of the process, relative paths are resolved against the base directory of the agent
+	WorkingDir string
+	// ShutdownSignal is sent to the process group on shutdown. If the processes do not exit
+	// within the ShutdownGracePeriod, the process group is killed.
+	ShutdownSignal      ShutdownSignal
+	ShutdownGracePeriod time.Duration
+}
+
+type ShutdownSignal int
+
+const (
+	Kill ShutdownSignal = iota
+	Terminate
+	Interrupt
+)
+
+type ShutdownEndpointCommand struct {
+	Name string
+}
+
+// GetOutputCommand returns the buffered output of a process, or only the last Tail lines if set.
+type GetOutputCommand struct {
+	Name string
+	Tail int
+}
+
+// AssertRunningCommand fails if the process of the endpoint has exited.
+type AssertRunningCommand struct {
+	Name string
+}
+
+// AssertExitedCommand waits until the process of the endpoint exits and validates its exit code.
+type AssertExitedCommand struct {
+	Name     string
+	ExitCode int
+	Timeout  time.Duration
+}
+
+type OutputStream int
+
+const (
+	Stdout OutputStream = iota
+	Stderr
+)
+
+type OutputLine struct {
+	Stream OutputStream
+	Line   string
+	Time   time.Time
+}
+
+// ExitEvent is published to the session when a process exits without being shut down by the agent.
+type ExitEvent struct {
+	EndpointName string
+	ExitCode     int
+	Status       string
+}
+
+// OutputEvent is published to the session for every output line of processes started with StreamOutput.
+type OutputEvent struct {
+	EndpointName string
+	Line         *OutputLine
+}
+
+func (s ShutdownSignal) String() string {
+	switch s {
+	case Terminate:
+		return "SIGTERM"
+	case Interrupt:
+		return "SIGINT"
+	default:
+		return "SIGKILL"
+	}
+}
+
+func (s OutputStream) String() string {
+	if s == Stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// ReadinessProbe defines when a started process is considered ready.
+// Exactly one of Tcp, Http or Output must be set.
+type ReadinessProbe struct {
+	Tcp     *TcpProbe
+	Http    *HttpProbe
+	Output  *OutputProbe
+	Timeout time.Duration
+}
+
+// TcpProbe waits until the address (host:port) accepts connections.
+type TcpProbe struct {
+	Address string
+}
+
+// HttpProbe waits until a GET on the url returns the expected status code.
+type HttpProbe struct {
+	Url            string
+	ExpectedStatus int
+}
+
+// OutputProbe waits until a line of stdout or stderr matches the regex pattern.
+type OutputProbe struct {
+	Pattern string
+}
+
+type InitEndpointResult struct {
+	Error error
+}
+
+type ShutdownEndpointResult struct {
+	Error error
+}
+
+type GetOutputResult struct {
+	Lines []*OutputLine
+	Error error
+}
+
+type AssertRunningResult struct {
+	Error error
+}
+
+type AssertExitedResult struct {
+	ExitCode int
+	Error    error
+}
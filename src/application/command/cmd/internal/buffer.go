@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"sync"
+)
+
+// outputBuffer keeps the most recent output lines of a process.
+type outputBuffer struct {
+	lock  sync.RWMutex
+	lines []*commands.OutputLine
+	next  int
+	full  bool
+}
+
+func newOutputBuffer(size int) *outputBuffer {
+	return &outputBuffer{
+		lines: make([]*commands.OutputLine, size),
+	}
+}
+
+func (b *outputBuffer) add(line *commands.OutputLine) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// tail returns the last n lines in the order they were added, or all lines if n <= 0
+func (b *outputBuffer) tail(n int) []*commands.OutputLine {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	var ordered []*commands.OutputLine
+	if b.full {
+		ordered = append(ordered, b.lines[b.next:]...)
+	}
+	ordered = append(ordered, b.lines[:b.next]...)
+
+	if n > 0 && n < len(ordered) {
+		return ordered[len(ordered)-n:]
+	}
+	return ordered
+}
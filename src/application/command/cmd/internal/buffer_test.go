@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"strconv"
+	"testing"
+)
+
+func TestBufferKeepsMostRecentLines(t *testing.T) {
+	buffer := newOutputBuffer(3)
+	for i := 1; i <= 5; i++ {
+		buffer.add(&commands.OutputLine{Line: strconv.Itoa(i)})
+	}
+
+	assertLines(t, buffer.tail(0), "3", "4", "5")
+	assertLines(t, buffer.tail(2), "4", "5")
+	assertLines(t, buffer.tail(10), "3", "4", "5")
+}
+
+func TestBufferNotFull(t *testing.T) {
+	buffer := newOutputBuffer(3)
+	buffer.add(&commands.OutputLine{Line: "1"})
+
+	assertLines(t, buffer.tail(0), "1")
+	assertLines(t, newOutputBuffer(3).tail(0))
+}
+
+func assertLines(t *testing.T, lines []*commands.OutputLine, expected ...string) {
+	t.Helper()
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines but received %d", len(expected), len(lines))
+	}
+	for i, line := range lines {
+		if line.Line != expected[i] {
+			t.Errorf("expected <%s> but received <%s>", expected[i], line.Line)
+		}
+	}
+}
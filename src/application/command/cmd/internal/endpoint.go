@@ -0,0 +1,333 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"github.com/go-clarum/agent/application/control"
+	"github.com/go-clarum/agent/application/utils/durations"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"github.com/go-clarum/agent/infrastructure/sync"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+)
+
+const shutdownTimeout = 10 * time.Second
+const outputWaitDelay = 1 * time.Second
+const defaultOutputBufferLines = 1000
+
+type Endpoint struct {
+	Name          string
+	cmdComponents []string
+	warmup        time.Duration
+	probes        []*commands.ReadinessProbe
+	env           []string
+	workingDir    string
+	stopSignal    commands.ShutdownSignal
+	gracePeriod   time.Duration
+	cmd           *exec.Cmd
+	cmdCancel     context.CancelFunc
+	output        *sync.Emitter
+	outputBuffer  *outputBuffer
+	streamOutput  bool
+	publish       func(event any)
+	stdout        *outputWriter
+	stderr        *outputWriter
+	exited        chan struct{}
+	exitError     error
+	stopping      atomic.Bool
+	logger        *logging.Logger
+}
+
+// NewEndpoint creates a command endpoint, publish is used to send events to the session that created it.
+func NewEndpoint(ic *commands.InitEndpointCommand, publish func(event any)) (*Endpoint, error) {
+	if clarumstrings.IsBlank(ic.Name) {
+		return nil, errors.New("cannot create command endpoint - name is empty")
+	}
+
+	if len(ic.CmdComponents) == 0 || clarumstrings.IsBlank(ic.CmdComponents[0]) {
+		return nil, errors.New(fmt.Sprintf("cannot create command endpoint [%s] - cmd is empty", ic.Name))
+	}
+
+	return &Endpoint{
+		Name:          ic.Name,
+		cmdComponents: ic.CmdComponents,
+		warmup:        durations.GetDurationWithDefault(ic.Warmup, 1*time.Millisecond),
+		probes:        ic.ReadinessProbes,
+		env:           environment(ic.Env, ic.ReplaceEnv),
+		workingDir:    workingDir(ic.WorkingDir),
+		stopSignal:    ic.ShutdownSignal,
+		gracePeriod:   durations.GetDurationWithDefault(ic.ShutdownGracePeriod, shutdownTimeout),
+		output:        sync.NewEmitter(),
+		outputBuffer:  newOutputBuffer(outputBufferSize(ic.OutputBufferLines)),
+		streamOutput:  ic.StreamOutput,
+		publish:       publish,
+		exited:        make(chan struct{}),
+		logger:        logging.NewLogger(loggerName(ic.Name)),
+	}, nil
+}
+
+// Start the process from the given command & arguments.
+// The process will be started into a cancelable context so that we can
+// cancel it later in the post-integration test phase.
+// Start returns once all readiness probes succeeded and the warmup has passed.
+// A process that fails during this time is reported as a failed start.
+func (endpoint *Endpoint) Start() error {
+	probes, err := endpoint.prepareProbes()
+	if err != nil {
+		return endpoint.handleError("invalid readiness probes", err)
+	}
+
+	endpoint.logger.Infof("running cmd [%s]", endpoint.cmdComponents)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	endpoint.cmd = exec.CommandContext(ctx, endpoint.cmdComponents[0], endpoint.cmdComponents[1:]...)
+	endpoint.cmdCancel = cancel
+	endpoint.cmd.Env = endpoint.env
+	endpoint.cmd.Dir = endpoint.workingDir
+	setProcessGroup(endpoint.cmd)
+	// canceling the context kills the processes started by the cmd as well
+	endpoint.cmd.Cancel = func() error {
+		return killProcessGroup(endpoint.cmd)
+	}
+
+	endpoint.stdout = newOutputWriter(commands.Stdout, endpoint.handleOutput)
+	endpoint.stderr = newOutputWriter(commands.Stderr, endpoint.handleOutput)
+	endpoint.cmd.Stdout = endpoint.stdout
+	endpoint.cmd.Stderr = endpoint.stderr
+	// processes started in the background by the cmd may keep the output open after it exits
+	endpoint.cmd.WaitDelay = outputWaitDelay
+
+	endpoint.logger.Debug("starting command")
+	if err := endpoint.cmd.Start(); err != nil {
+		cancel()
+		releaseProbes(probes)
+		return endpoint.handleError("unable to start cmd", err)
+	} else {
+		endpoint.logger.Debug("cmd start successful")
+	}
+
+	go endpoint.waitForExit()
+
+	if err := endpoint.awaitReadiness(probes); err != nil {
+		// the process is of no use if it is not ready, so we do not leave it running
+		_ = endpoint.Shutdown()
+		return endpoint.handleError("process is not ready", err)
+	}
+
+	select {
+	case <-endpoint.exited:
+		if endpoint.exitError != nil {
+			return endpoint.handleError("process failed during warmup", endpoint.exitError)
+		}
+		endpoint.logger.Info("process finished during warmup")
+	case <-time.After(endpoint.warmup):
+		endpoint.logger.Debug("warmup ended")
+	}
+
+	return nil
+}
+
+// shutdown the running process. The configured shutdown signal is sent to the process group first;
+// if the processes do not exit within the grace period, the context is canceled, which kills the process group.
+// The process group is killed on every path, so that no process started by the cmd survives it.
+// We also wait for the process to exit here, so that the post-integration test phase ends successfully.
+func (endpoint *Endpoint) Shutdown() error {
+	control.RunningActions.Add(1)
+	defer control.RunningActions.Done()
+
+	endpoint.logger.Infof("stopping cmd [%s]", endpoint.cmdComponents)
+
+	select {
+	case <-endpoint.exited:
+		endpoint.logger.Info("process has already exited")
+		endpoint.killProcess()
+		return nil
+	default:
+	}
+
+	endpoint.stopping.Store(true)
+	if endpoint.stopSignal != commands.Kill {
+		if endpoint.signalProcess() {
+			endpoint.killProcess()
+			return nil
+		}
+	}
+
+	endpoint.logger.Debug("cancelling cmd")
+	endpoint.cmdCancel()
+
+	select {
+	case <-endpoint.exited:
+		endpoint.logger.Debug("context cancel finished successfully")
+		endpoint.killProcess()
+		return nil
+	case <-time.After(shutdownTimeout):
+		endpoint.killProcess()
+		return endpoint.handleError("process did not stop after context cancel", nil)
+	}
+}
+
+// Output returns the last buffered output lines of the process, or all of them if tail <= 0.
+func (endpoint *Endpoint) Output(tail int) []*commands.OutputLine {
+	return endpoint.outputBuffer.tail(tail)
+}
+
+// handleOutput is called for every line the process writes to stdout or stderr
+func (endpoint *Endpoint) handleOutput(line *commands.OutputLine) {
+	endpoint.logger.Infof("%s | %s", line.Stream, line.Line)
+	endpoint.outputBuffer.add(line)
+	endpoint.output.Send(line.Line)
+
+	if endpoint.streamOutput {
+		endpoint.publish(&commands.OutputEvent{
+			EndpointName: endpoint.Name,
+			Line:         line,
+		})
+	}
+}
+
+// the exit status can only be collected once, so every other part of the endpoint uses the exited channel
+func (endpoint *Endpoint) waitForExit() {
+	endpoint.exitError = endpoint.cmd.Wait()
+	endpoint.stdout.flush()
+	endpoint.stderr.flush()
+	close(endpoint.exited)
+
+	if endpoint.stopping.Load() {
+		endpoint.logger.Infof("process exited - %s", endpoint.cmd.ProcessState)
+		return
+	}
+
+	endpoint.logger.Warnf("process exited unexpectedly - %s", endpoint.cmd.ProcessState)
+	endpoint.publish(&commands.ExitEvent{
+		EndpointName: endpoint.Name,
+		ExitCode:     endpoint.cmd.ProcessState.ExitCode(),
+		Status:       endpoint.cmd.ProcessState.String(),
+	})
+}
+
+// AssertRunning returns an error if the process has exited.
+func (endpoint *Endpoint) AssertRunning() error {
+	select {
+	case <-endpoint.exited:
+		return endpoint.handleError(fmt.Sprintf("process is not running - %s", endpoint.cmd.ProcessState), nil)
+	default:
+		endpoint.logger.Info("process is running")
+		return nil
+	}
+}
+
+// AssertExited waits for the process to exit and validates its exit code.
+// Processes killed by a signal have the exit code -1.
+func (endpoint *Endpoint) AssertExited(expectedExitCode int, timeout time.Duration) (int, error) {
+	select {
+	case <-endpoint.exited:
+		exitCode := endpoint.cmd.ProcessState.ExitCode()
+		if exitCode != expectedExitCode {
+			return exitCode, endpoint.handleError(fmt.Sprintf("validation error - exit code mismatch - expected [%d] but received [%d] (%s)",
+				expectedExitCode, exitCode, endpoint.cmd.ProcessState), nil)
+		}
+
+		endpoint.logger.Info("exit code validation successful")
+		return exitCode, nil
+	case <-time.After(durations.GetDurationWithDefault(timeout, config.ActionTimeout())):
+		return 0, endpoint.handleError("assert exited action timed out - process is still running", nil)
+	}
+}
+
+// signalProcess sends the shutdown signal to the process group and waits for the processes to exit
+// during the grace period. Returns false if the process is still running afterward.
+func (endpoint *Endpoint) signalProcess() bool {
+	endpoint.logger.Debugf("sending %s to process group", endpoint.stopSignal)
+	if err := signalProcessGroup(endpoint.cmd, endpoint.stopSignal); err != nil {
+		endpoint.logger.Errorf("unable to send %s - [%s]", endpoint.stopSignal, err)
+		return false
+	}
+
+	gracePeriod := time.After(endpoint.gracePeriod)
+	select {
+	case <-endpoint.exited:
+		endpoint.logger.Debugf("process stopped after %s", endpoint.stopSignal)
+	case <-gracePeriod:
+		endpoint.logger.Warnf("process did not stop within the grace period of %s", endpoint.gracePeriod)
+		return false
+	}
+
+	// the other processes of the group get the rest of the grace period to handle the signal
+	for processGroupExists(endpoint.cmd) {
+		select {
+		case <-gracePeriod:
+			endpoint.logger.Warnf("process group did not stop within the grace period of %s", endpoint.gracePeriod)
+			return true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return true
+}
+
+// killProcess kills the whole process group, the processes that have already exited are ignored
+func (endpoint *Endpoint) killProcess() {
+	endpoint.logger.Debug("killing process group")
+
+	if err := killProcessGroup(endpoint.cmd); err != nil {
+		endpoint.logger.Errorf("unable to kill process group - [%s]", err)
+	}
+}
+
+func (endpoint *Endpoint) handleError(message string, err error) error {
+	var errorMessage string
+	if err != nil {
+		errorMessage = message + " - " + err.Error()
+	} else {
+		errorMessage = message
+	}
+	endpoint.logger.Errorf(errorMessage)
+	return errors.New(endpoint.logger.Name() + " " + errorMessage)
+}
+
+// environment returns nil when the process should inherit the environment of the agent
+func environment(env map[string]string, replace bool) []string {
+	if len(env) == 0 && !replace {
+		return nil
+	}
+
+	var result []string
+	if !replace {
+		result = os.Environ()
+	}
+	// exec uses the last value of duplicate keys, so the given variables overwrite the inherited ones
+	for key, value := range env {
+		result = append(result, key+"="+value)
+	}
+	if result == nil {
+		// an empty, non-nil environment keeps the process from inheriting the one of the agent
+		result = []string{}
+	}
+
+	return result
+}
+
+func workingDir(dir string) string {
+	if clarumstrings.IsBlank(dir) {
+		return dir
+	}
+	return config.ResolvePath(dir)
+}
+
+func outputBufferSize(lines int) int {
+	if lines > 0 {
+		return lines
+	}
+	return defaultOutputBufferLines
+}
+
+func loggerName(cmdName string) string {
+	return fmt.Sprintf("Command %s", cmdName)
+}
@@ -0,0 +1,469 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEndpointValidation(t *testing.T) {
+	if _, err := NewEndpoint(&commands.InitEndpointCommand{CmdComponents: []string{"sleep"}}, noEvents); err == nil {
+		t.Errorf("error expected for empty name")
+	}
+
+	if _, err := NewEndpoint(&commands.InitEndpointCommand{Name: "sut"}, noEvents); err == nil {
+		t.Errorf("error expected for empty cmd")
+	}
+}
+
+func TestStartUnknownBinary(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"./this-binary-does-not-exist"},
+	}, noEvents)
+
+	err := endpoint.Start()
+
+	if err == nil {
+		t.Fatalf("start error expected, but got none")
+	}
+	if !strings.HasPrefix(err.Error(), "Command sut unable to start cmd") {
+		t.Errorf("start error message is unexpected: %s", err)
+	}
+}
+
+func TestStartAndShutdown(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sleep", "30"},
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+
+	if err := endpoint.Shutdown(); err != nil {
+		t.Errorf("no shutdown error expected, but got %s", err)
+	}
+
+	select {
+	case <-endpoint.exited:
+	default:
+		t.Errorf("expected process to have exited")
+	}
+
+	// a second shutdown finds the process already stopped
+	if err := endpoint.Shutdown(); err != nil {
+		t.Errorf("no shutdown error expected, but got %s", err)
+	}
+}
+
+func TestStartFailsDuringWarmup(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "exit 3"},
+		Warmup:        2 * time.Second,
+	}, noEvents)
+
+	err := endpoint.Start()
+
+	if err == nil {
+		t.Fatalf("start error expected, but got none")
+	}
+	if err.Error() != "Command sut process failed during warmup - exit status 3" {
+		t.Errorf("start error message is unexpected: %s", err)
+	}
+}
+
+func TestOutputProbe(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "sleep 0.2; echo 'server started on port 8080'; sleep 30"},
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Output: &commands.OutputProbe{Pattern: "started on port \\d+"}, Timeout: 5 * time.Second},
+		},
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	_ = endpoint.Shutdown()
+}
+
+func TestOutputProbeProcessExits(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "echo booting; exit 1"},
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Output: &commands.OutputProbe{Pattern: "ready"}, Timeout: 5 * time.Second},
+		},
+	}, noEvents)
+
+	start := time.Now()
+	err := endpoint.Start()
+
+	if err == nil {
+		t.Fatalf("start error expected, but got none")
+	}
+	if !strings.Contains(err.Error(), "readiness probe output [ready] failed - process exited before becoming ready") {
+		t.Errorf("start error message is unexpected: %s", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected the probe to fail as soon as the process exits")
+	}
+}
+
+func TestTcpProbe(t *testing.T) {
+	listener, _ := net.Listen("tcp", "localhost:0")
+	defer listener.Close()
+
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sleep", "30"},
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Tcp: &commands.TcpProbe{Address: listener.Addr().String()}},
+		},
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	_ = endpoint.Shutdown()
+}
+
+func TestHttpProbeTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sleep", "30"},
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Http: &commands.HttpProbe{Url: server.URL + "/health"}, Timeout: 300 * time.Millisecond},
+		},
+	}, noEvents)
+
+	err := endpoint.Start()
+
+	if err == nil {
+		t.Fatalf("start error expected, but got none")
+	}
+	if !strings.Contains(err.Error(), "not ready after 300ms - received status [503]") {
+		t.Errorf("start error message is unexpected: %s", err)
+	}
+
+	// a process that is not ready must not be left running
+	select {
+	case <-endpoint.exited:
+	default:
+		t.Errorf("expected process to be stopped")
+	}
+}
+
+func TestInvalidProbe(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:            "sut",
+		CmdComponents:   []string{"sleep", "30"},
+		ReadinessProbes: []*commands.ReadinessProbe{{Output: &commands.OutputProbe{Pattern: "("}}},
+	}, noEvents)
+
+	if err := endpoint.Start(); err == nil {
+		t.Errorf("start error expected, but got none")
+	}
+	if endpoint.cmd != nil {
+		t.Errorf("expected the process not to be started")
+	}
+}
+
+func TestOutput(t *testing.T) {
+	events := make(chan any, 10)
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "echo one; echo two >&2; echo three"},
+		StreamOutput:  true,
+	}, func(event any) {
+		events <- event
+	})
+
+	_ = endpoint.Start()
+	<-endpoint.exited
+
+	lines := endpoint.Output(0)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines but received %d", len(lines))
+	}
+	for _, line := range lines {
+		if line.Line == "two" && line.Stream != commands.Stderr {
+			t.Errorf("expected line <two> on stderr")
+		}
+	}
+	if tail := endpoint.Output(1); len(tail) != 1 {
+		t.Errorf("expected 1 line but received %d", len(tail))
+	}
+
+	outputEvents := 0
+	for len(events) > 0 {
+		if _, ok := (<-events).(*commands.OutputEvent); ok {
+			outputEvents++
+		}
+	}
+	if outputEvents != 3 {
+		t.Errorf("expected 3 output events but received %d", outputEvents)
+	}
+}
+
+func noEvents(_ any) {}
+
+func TestUnexpectedExit(t *testing.T) {
+	events := make(chan any, 10)
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "sleep 0.2; exit 4"},
+	}, func(event any) {
+		events <- event
+	})
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	if err := endpoint.AssertRunning(); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+
+	select {
+	case event := <-events:
+		exitEvent := event.(*commands.ExitEvent)
+		if exitEvent.ExitCode != 4 || exitEvent.EndpointName != "sut" {
+			t.Errorf("exit event is unexpected: %v", exitEvent)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected an exit event")
+	}
+
+	if err := endpoint.AssertRunning(); err == nil {
+		t.Errorf("error expected, but got none")
+	}
+	if exitCode, err := endpoint.AssertExited(4, time.Second); err != nil || exitCode != 4 {
+		t.Errorf("no error expected, but got %s", err)
+	}
+
+	_, err := endpoint.AssertExited(0, time.Second)
+	if err == nil {
+		t.Fatalf("error expected, but got none")
+	}
+	if err.Error() != "Command sut validation error - exit code mismatch - expected [0] but received [4] (exit status 4)" {
+		t.Errorf("error message is unexpected: %s", err)
+	}
+}
+
+func TestShutdownIsNoUnexpectedExit(t *testing.T) {
+	events := make(chan any, 10)
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sleep", "30"},
+	}, func(event any) {
+		events <- event
+	})
+
+	_ = endpoint.Start()
+	_ = endpoint.Shutdown()
+
+	if len(events) != 0 {
+		t.Errorf("expected no exit event")
+	}
+}
+
+func TestAssertExitedTimeout(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sleep", "30"},
+	}, noEvents)
+
+	_ = endpoint.Start()
+	defer endpoint.Shutdown()
+
+	if _, err := endpoint.AssertExited(0, 100*time.Millisecond); err == nil {
+		t.Errorf("error expected, but got none")
+	}
+}
+
+func TestEnvironmentAndWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "echo \"$GREETING\" && pwd"},
+		Env:           map[string]string{"GREETING": "hello"},
+		WorkingDir:    dir,
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	if _, err := endpoint.AssertExited(0, 5*time.Second); err != nil {
+		t.Fatalf("no exit error expected, but got %s", err)
+	}
+
+	lines := endpoint.Output(0)
+	if len(lines) != 2 || lines[0].Line != "hello" || lines[1].Line != dir {
+		t.Errorf("unexpected output %v", lines)
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("AGENT_TEST_VAR", "agent")
+
+	merged := environment(map[string]string{"OTHER": "value"}, false)
+	if !containsEntry(merged, "AGENT_TEST_VAR=agent") || !containsEntry(merged, "OTHER=value") {
+		t.Errorf("expected merged environment, got %v", merged)
+	}
+
+	replaced := environment(map[string]string{"OTHER": "value"}, true)
+	if len(replaced) != 1 || replaced[0] != "OTHER=value" {
+		t.Errorf("expected replaced environment, got %v", replaced)
+	}
+
+	if empty := environment(nil, true); empty == nil || len(empty) != 0 {
+		t.Errorf("expected empty environment, got %v", empty)
+	}
+	if inherited := environment(nil, false); inherited != nil {
+		t.Errorf("expected inherited environment, got %v", inherited)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:           "sut",
+		CmdComponents:  []string{"sh", "-c", "trap 'echo stopping; exit 0' TERM; echo started; while true; do sleep 0.1; done"},
+		ShutdownSignal: commands.Terminate,
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Output: &commands.OutputProbe{Pattern: "started"}},
+		},
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	if err := endpoint.Shutdown(); err != nil {
+		t.Fatalf("no shutdown error expected, but got %s", err)
+	}
+
+	if exitCode := endpoint.cmd.ProcessState.ExitCode(); exitCode != 0 {
+		t.Errorf("expected the process to exit gracefully, but got exit code %d", exitCode)
+	}
+	handled := false
+	for _, line := range endpoint.Output(0) {
+		handled = handled || line.Line == "stopping"
+	}
+	if !handled {
+		t.Errorf("expected the process to handle the signal")
+	}
+}
+
+func TestShutdownKillsAfterGracePeriod(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:                "sut",
+		CmdComponents:       []string{"sh", "-c", "trap '' TERM; sleep 30 & wait"},
+		ShutdownSignal:      commands.Terminate,
+		ShutdownGracePeriod: 200 * time.Millisecond,
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+
+	start := time.Now()
+	if err := endpoint.Shutdown(); err != nil {
+		t.Fatalf("no shutdown error expected, but got %s", err)
+	}
+
+	// the background sleep would keep the output open if it was not killed together with the shell
+	if elapsed := time.Since(start); elapsed > outputWaitDelay {
+		t.Errorf("expected the process group to be killed after the grace period, but shutdown took %s", elapsed)
+	}
+	if exitCode := endpoint.cmd.ProcessState.ExitCode(); exitCode != -1 {
+		t.Errorf("expected the process to be killed, but got exit code %d", exitCode)
+	}
+}
+
+func TestShutdownKillsProcessGroupAfterProcessStopped(t *testing.T) {
+	dir := t.TempDir()
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name: "sut",
+		CmdComponents: []string{"sh", "-c", "(trap '' TERM; sleep 0.5; touch orphan) >/dev/null 2>&1 & " +
+			"trap 'exit 0' TERM; echo started; while true; do sleep 0.1; done"},
+		WorkingDir:          dir,
+		ShutdownSignal:      commands.Terminate,
+		ShutdownGracePeriod: 200 * time.Millisecond,
+		ReadinessProbes: []*commands.ReadinessProbe{
+			{Output: &commands.OutputProbe{Pattern: "started"}},
+		},
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	if err := endpoint.Shutdown(); err != nil {
+		t.Fatalf("no shutdown error expected, but got %s", err)
+	}
+
+	assertOrphanKilled(t, dir)
+}
+
+func TestShutdownKillsProcessGroupAfterProcessExited(t *testing.T) {
+	dir := t.TempDir()
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{
+		Name:          "sut",
+		CmdComponents: []string{"sh", "-c", "(trap '' TERM; sleep 0.5; touch orphan) >/dev/null 2>&1 &"},
+		WorkingDir:    dir,
+	}, noEvents)
+
+	if err := endpoint.Start(); err != nil {
+		t.Fatalf("no start error expected, but got %s", err)
+	}
+	if _, err := endpoint.AssertExited(0, 5*time.Second); err != nil {
+		t.Fatalf("no exit error expected, but got %s", err)
+	}
+	if err := endpoint.Shutdown(); err != nil {
+		t.Fatalf("no shutdown error expected, but got %s", err)
+	}
+
+	assertOrphanKilled(t, dir)
+}
+
+// the orphan creates its file after 0.5s, unless it was killed together with the process
+func assertOrphanKilled(t *testing.T, dir string) {
+	time.Sleep(time.Second)
+	if _, err := os.Stat(filepath.Join(dir, "orphan")); err == nil {
+		t.Errorf("expected the orphaned process to be killed with the process group")
+	}
+}
+
+func TestWorkingDir(t *testing.T) {
+	if dir := workingDir(""); dir != "" {
+		t.Errorf("expected empty working dir, got %s", dir)
+	}
+	if dir := workingDir("/tmp"); dir != "/tmp" {
+		t.Errorf("expected absolute working dir to be kept, got %s", dir)
+	}
+	if dir := workingDir("sut"); dir != filepath.Join(config.BaseDir(), "sut") {
+		t.Errorf("expected working dir relative to the base dir, got %s", dir)
+	}
+}
+
+func containsEntry(env []string, entry string) bool {
+	for _, e := range env {
+		if e == entry {
+			return true
+		}
+	}
+	return false
+}
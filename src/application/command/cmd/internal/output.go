@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"bytes"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxOutputLineSize = 64 * 1024
+
+// outputWriter receives the stdout or stderr of the process and passes it on line by line.
+// Lines longer than maxOutputLineSize are split at a rune boundary, invalid UTF-8 is replaced,
+// since the lines are sent as strings to the session.
+type outputWriter struct {
+	stream  commands.OutputStream
+	onLine  func(line *commands.OutputLine)
+	pending []byte
+}
+
+func newOutputWriter(stream commands.OutputStream, onLine func(line *commands.OutputLine)) *outputWriter {
+	return &outputWriter{
+		stream: stream,
+		onLine: onLine,
+	}
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.publish(w.pending[:i])
+		w.pending = w.pending[i+1:]
+	}
+
+	for len(w.pending) >= maxOutputLineSize {
+		i := runeBoundary(w.pending, maxOutputLineSize)
+		w.publish(w.pending[:i])
+		w.pending = w.pending[i:]
+	}
+
+	return len(p), nil
+}
+
+// flush publishes the last line, if the output did not end with a line break
+func (w *outputWriter) flush() {
+	if len(w.pending) > 0 {
+		w.publish(w.pending)
+		w.pending = nil
+	}
+}
+
+func (w *outputWriter) publish(line []byte) {
+	w.onLine(&commands.OutputLine{
+		Stream: w.stream,
+		Line:   strings.ToValidUTF8(string(bytes.TrimSuffix(line, []byte("\r"))), string(utf8.RuneError)),
+		Time:   time.Now(),
+	})
+}
+
+// runeBoundary moves the split back to the start of the rune at max, so that the rune is not split
+func runeBoundary(line []byte, max int) int {
+	for i := max; i > max-utf8.UTFMax && i > 0; i-- {
+		if utf8.RuneStart(line[i]) {
+			return i
+		}
+	}
+	return max
+}
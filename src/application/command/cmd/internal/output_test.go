@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOutputWriterSplitsLines(t *testing.T) {
+	var lines []*commands.OutputLine
+	writer := newOutputWriter(commands.Stderr, func(line *commands.OutputLine) {
+		lines = append(lines, line)
+	})
+
+	_, _ = writer.Write([]byte("first\r\nsec"))
+	_, _ = writer.Write([]byte("ond\nthird"))
+	writer.flush()
+
+	assertLines(t, lines, "first", "second", "third")
+	if lines[0].Stream != commands.Stderr {
+		t.Errorf("expected lines of <stderr>")
+	}
+}
+
+func TestOutputWriterSplitsLongLinesAtRuneBoundary(t *testing.T) {
+	var lines []*commands.OutputLine
+	writer := newOutputWriter(commands.Stdout, func(line *commands.OutputLine) {
+		lines = append(lines, line)
+	})
+
+	// the 2 byte rune starts at the last byte before the split
+	long := strings.Repeat("a", maxOutputLineSize-1) + "é" + "b"
+	_, _ = writer.Write([]byte(long))
+	writer.flush()
+
+	if len(lines) != 2 || lines[0].Line+lines[1].Line != long || lines[1].Line != "éb" {
+		t.Errorf("expected the line to be split before the rune, but got %d lines", len(lines))
+	}
+}
+
+func TestOutputWriterReplacesInvalidUtf8(t *testing.T) {
+	var lines []*commands.OutputLine
+	writer := newOutputWriter(commands.Stdout, func(line *commands.OutputLine) {
+		lines = append(lines, line)
+	})
+
+	_, _ = writer.Write([]byte{'b', 0xff, 0xfe, 'n', '\n'})
+
+	assertLines(t, lines, "b\uFFFDn")
+	if !utf8.ValidString(lines[0].Line) {
+		t.Errorf("expected valid UTF-8")
+	}
+}
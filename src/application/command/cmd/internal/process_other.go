@@ -0,0 +1,34 @@
+//go:build !unix
+
+package internal
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"os"
+	"os/exec"
+)
+
+// process groups are not supported on this platform, so we only handle the process itself
+
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+func signalProcessGroup(cmd *exec.Cmd, signal commands.ShutdownSignal) error {
+	if signal == commands.Kill {
+		return cmd.Process.Kill()
+	}
+
+	return errors.New("only the kill signal is supported on this platform")
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+func processGroupExists(cmd *exec.Cmd) bool {
+	return false
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package internal
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"os/exec"
+	"syscall"
+)
+
+// the process is started in its own process group, so that the processes
+// it starts in turn can be signaled and killed together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalProcessGroup(cmd *exec.Cmd, signal commands.ShutdownSignal) error {
+	return syscall.Kill(-cmd.Process.Pid, toSyscallSignal(signal))
+}
+
+// killProcessGroup does not return an error if all processes of the group have already exited
+func killProcessGroup(cmd *exec.Cmd) error {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+func processGroupExists(cmd *exec.Cmd) bool {
+	return syscall.Kill(-cmd.Process.Pid, 0) == nil
+}
+
+func toSyscallSignal(signal commands.ShutdownSignal) syscall.Signal {
+	switch signal {
+	case commands.Terminate:
+		return syscall.SIGTERM
+	case commands.Interrupt:
+		return syscall.SIGINT
+	default:
+		return syscall.SIGKILL
+	}
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	"github.com/go-clarum/agent/application/utils/durations"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const probeInterval = 100 * time.Millisecond
+
+type probe struct {
+	description string
+	timeout     time.Duration
+	// await blocks until the probe succeeds or the context is done
+	await func(ctx context.Context) error
+	// release frees what the probe has reserved, called once the probe is done
+	release func()
+}
+
+// prepareProbes validates the probe definitions. Output probes subscribe to the process output here,
+// before the process is started, so that no line is missed.
+func (endpoint *Endpoint) prepareProbes() ([]*probe, error) {
+	var result []*probe
+
+	for _, definition := range endpoint.probes {
+		p, err := endpoint.newProbe(definition)
+		if err != nil {
+			releaseProbes(result)
+			return nil, err
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
+
+func (endpoint *Endpoint) newProbe(definition *commands.ReadinessProbe) (*probe, error) {
+	if definition == nil {
+		return nil, errors.New("readiness probe is empty")
+	}
+
+	timeout := durations.GetDurationWithDefault(definition.Timeout, config.ActionTimeout())
+
+	switch {
+	case definition.Tcp != nil:
+		if clarumstrings.IsBlank(definition.Tcp.Address) {
+			return nil, errors.New("tcp readiness probe is invalid - address is empty")
+		}
+		return &probe{
+			description: fmt.Sprintf("tcp [%s]", definition.Tcp.Address),
+			timeout:     timeout,
+			await:       tcpProbe(definition.Tcp.Address),
+			release:     func() {},
+		}, nil
+	case definition.Http != nil:
+		if clarumstrings.IsBlank(definition.Http.Url) {
+			return nil, errors.New("http readiness probe is invalid - url is empty")
+		}
+		expectedStatus := definition.Http.ExpectedStatus
+		if expectedStatus == 0 {
+			expectedStatus = http.StatusOK
+		}
+		return &probe{
+			description: fmt.Sprintf("http [%s] status [%d]", definition.Http.Url, expectedStatus),
+			timeout:     timeout,
+			await:       httpProbe(definition.Http.Url, expectedStatus),
+			release:     func() {},
+		}, nil
+	case definition.Output != nil:
+		pattern, err := regexp.Compile(definition.Output.Pattern)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("output readiness probe is invalid - %s", err))
+		}
+		lines := endpoint.output.Subscribe()
+		return &probe{
+			description: fmt.Sprintf("output [%s]", definition.Output.Pattern),
+			timeout:     timeout,
+			await:       outputProbe(pattern, lines),
+			release: func() {
+				endpoint.output.Unsubscribe(lines)
+			},
+		}, nil
+	default:
+		return nil, errors.New("readiness probe is invalid - no probe type set")
+	}
+}
+
+// awaitReadiness runs all probes in parallel. A probe fails when its timeout is reached
+// or when the process exits before the probe succeeds.
+func (endpoint *Endpoint) awaitReadiness(probes []*probe) error {
+	defer releaseProbes(probes)
+
+	var wg sync.WaitGroup
+	probeErrors := make([]error, len(probes))
+
+	for i, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeErrors[i] = endpoint.awaitProbe(p)
+		}()
+	}
+
+	wg.Wait()
+	return errors.Join(probeErrors...)
+}
+
+func (endpoint *Endpoint) awaitProbe(p *probe) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-endpoint.exited:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	start := time.Now()
+	if err := p.await(ctx); err != nil {
+		select {
+		case <-endpoint.exited:
+			return errors.New(fmt.Sprintf("readiness probe %s failed - process exited before becoming ready", p.description))
+		default:
+			return errors.New(fmt.Sprintf("readiness probe %s failed - not ready after %s - %s", p.description, p.timeout, err))
+		}
+	}
+
+	endpoint.logger.Infof("readiness probe %s succeeded after %s", p.description, time.Since(start))
+	return nil
+}
+
+func releaseProbes(probes []*probe) {
+	for _, p := range probes {
+		p.release()
+	}
+}
+
+func tcpProbe(address string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		dialer := net.Dialer{Timeout: probeInterval}
+
+		return poll(ctx, func() error {
+			conn, err := dialer.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+	}
+}
+
+func httpProbe(url string, expectedStatus int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		client := http.Client{Timeout: time.Second}
+
+		return poll(ctx, func() error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+
+			res, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			_ = res.Body.Close()
+
+			if res.StatusCode != expectedStatus {
+				return errors.New(fmt.Sprintf("received status [%d]", res.StatusCode))
+			}
+			return nil
+		})
+	}
+}
+
+func outputProbe(pattern *regexp.Regexp, lines chan string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for {
+			select {
+			case line := <-lines:
+				if pattern.MatchString(line) {
+					return nil
+				}
+			case <-ctx.Done():
+				return errors.New("no matching line in process output")
+			}
+		}
+	}
+}
+
+// poll calls check until it succeeds, returning the last check error if the context is done first
+func poll(ctx context.Context, check func() error) error {
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(probeInterval):
+		}
+	}
+}
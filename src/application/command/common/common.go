@@ -1,6 +1,12 @@
 package common
 
+import "github.com/go-clarum/agent/application/session"
+
 type CommandHandler interface {
-	CanHandle(command any) bool
-	Handle(command any) any
+	// HandledCommands returns one instance of every command type this handler is responsible for.
+	// The mediator routes commands to handlers based on these types.
+	HandledCommands() []any
+	Handle(session *session.Session, command any) any
+	// CloseSession shuts down every endpoint the given session created.
+	CloseSession(sessionId string)
 }
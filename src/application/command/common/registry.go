@@ -0,0 +1,66 @@
+package common
+
+import "sync"
+
+// EndpointRegistry keeps the endpoints of a handler, separated by the session that created them.
+type EndpointRegistry[E any] struct {
+	lock     sync.RWMutex
+	sessions map[string]map[string]E
+}
+
+func NewEndpointRegistry[E any]() *EndpointRegistry[E] {
+	return &EndpointRegistry[E]{
+		sessions: make(map[string]map[string]E),
+	}
+}
+
+func (r *EndpointRegistry[E]) Get(sessionId string, name string) (E, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	endpoint, exists := r.sessions[sessionId][name]
+	return endpoint, exists
+}
+
+// Put registers the endpoint and returns the one it replaced, if any.
+func (r *EndpointRegistry[E]) Put(sessionId string, name string, endpoint E) (E, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	endpoints, exists := r.sessions[sessionId]
+	if !exists {
+		endpoints = make(map[string]E)
+		r.sessions[sessionId] = endpoints
+	}
+
+	oldEndpoint, replaced := endpoints[name]
+	endpoints[name] = endpoint
+
+	return oldEndpoint, replaced
+}
+
+func (r *EndpointRegistry[E]) Remove(sessionId string, name string) (E, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	endpoint, exists := r.sessions[sessionId][name]
+	if exists {
+		delete(r.sessions[sessionId], name)
+	}
+
+	return endpoint, exists
+}
+
+// RemoveSession unregisters and returns all endpoints of the given session.
+func (r *EndpointRegistry[E]) RemoveSession(sessionId string) []E {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var result []E
+	for _, endpoint := range r.sessions[sessionId] {
+		result = append(result, endpoint)
+	}
+	delete(r.sessions, sessionId)
+
+	return result
+}
@@ -0,0 +1,52 @@
+package common
+
+import "testing"
+
+func TestRegistrySessionIsolation(t *testing.T) {
+	registry := NewEndpointRegistry[string]()
+
+	registry.Put("session1", "api", "endpoint1")
+	registry.Put("session2", "api", "endpoint2")
+
+	if endpoint, _ := registry.Get("session1", "api"); endpoint != "endpoint1" {
+		t.Errorf("expected <endpoint1> but received %s", endpoint)
+	}
+	if endpoint, _ := registry.Get("session2", "api"); endpoint != "endpoint2" {
+		t.Errorf("expected <endpoint2> but received %s", endpoint)
+	}
+	if _, exists := registry.Get("session3", "api"); exists {
+		t.Errorf("expected no endpoint for unknown session")
+	}
+}
+
+func TestRegistryReplace(t *testing.T) {
+	registry := NewEndpointRegistry[string]()
+
+	if _, replaced := registry.Put("session1", "api", "endpoint1"); replaced {
+		t.Errorf("expected <false>")
+	}
+
+	oldEndpoint, replaced := registry.Put("session1", "api", "endpoint2")
+	if !replaced || oldEndpoint != "endpoint1" {
+		t.Errorf("expected <endpoint1> to be replaced")
+	}
+}
+
+func TestRegistryRemoveSession(t *testing.T) {
+	registry := NewEndpointRegistry[string]()
+	registry.Put("session1", "api", "endpoint1")
+	registry.Put("session1", "db", "endpoint2")
+	registry.Put("session2", "api", "endpoint3")
+
+	removed := registry.RemoveSession("session1")
+
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed endpoints but received %d", len(removed))
+	}
+	if _, exists := registry.Get("session1", "api"); exists {
+		t.Errorf("expected endpoint to be removed")
+	}
+	if _, exists := registry.Get("session2", "api"); !exists {
+		t.Errorf("expected endpoint of other session to be kept")
+	}
+}
@@ -1,34 +1,60 @@
 package client
 
 import (
+	"errors"
+	"fmt"
 	"github.com/go-clarum/agent/application/command/common"
 	"github.com/go-clarum/agent/application/command/http/client/commands"
 	"github.com/go-clarum/agent/application/command/http/client/internal"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/application/command/http/common/validators"
+	"github.com/go-clarum/agent/application/session"
 	"github.com/go-clarum/agent/infrastructure/logging"
-	"net/http"
 )
 
 type handler struct {
-	endpoints map[string]*internal.Endpoint
+	endpoints *common.EndpointRegistry[*internal.Endpoint]
 	logger    *logging.Logger
 }
 
-func (h *handler) CanHandle(command any) bool {
-	return true
+func (h *handler) HandledCommands() []any {
+	return []any{
+		&commands.InitEndpointCommand{},
+		&commands.SendCommand{},
+		&commands.ReceiveCommand{},
+	}
 }
 
-func (h *handler) Handle(command any) any {
-	return nil
+func (h *handler) Handle(session *session.Session, command any) any {
+	switch c := command.(type) {
+	case *commands.InitEndpointCommand:
+		return &commands.InitEndpointResult{Error: h.InitializeEndpoint(session.Id, c)}
+	case *commands.SendCommand:
+		return &commands.SendResult{Error: h.SendAction(session.Id, session.Variables, c)}
+	case *commands.ReceiveCommand:
+		response, variables, err := h.ReceiveAction(session.Id, session.Variables, c)
+		return &commands.ReceiveResult{Response: response, Variables: variables, Error: err}
+	default:
+		h.logger.Errorf("unsupported command [%T]", command)
+		return nil
+	}
 }
 
 func NewHttpClientHandler() common.CommandHandler {
 	return &handler{
-		endpoints: make(map[string]*internal.Endpoint),
+		endpoints: common.NewEndpointRegistry[*internal.Endpoint](),
 		logger:    logging.NewLogger("HttpClientHandler"),
 	}
 }
 
-func (h *handler) InitializeEndpoint(req *commands.InitEndpointCommand) error {
+func (h *handler) CloseSession(sessionId string) {
+	for _, endpoint := range h.endpoints.RemoveSession(sessionId) {
+		h.logger.Infof("closing HTTP client endpoint [%s]", endpoint.Name)
+		endpoint.Close()
+	}
+}
+
+func (h *handler) InitializeEndpoint(sessionId string, req *commands.InitEndpointCommand) error {
 	newEndpoint, err := internal.NewEndpoint(req)
 
 	if err != nil {
@@ -36,32 +62,63 @@ func (h *handler) InitializeEndpoint(req *commands.InitEndpointCommand) error {
 		return err
 	}
 
-	if oldEndpoint, exists := h.endpoints[newEndpoint.Name]; exists {
-		h.logger.Infof("HTTP client endpoint [%s] already exists - replacing", oldEndpoint.Name)
+	if oldEndpoint, replaced := h.endpoints.Put(sessionId, newEndpoint.Name, newEndpoint); replaced {
+		h.logger.Infof("HTTP client endpoint [%s] already exists - replaced", oldEndpoint.Name)
 	}
 
-	h.endpoints[newEndpoint.Name] = newEndpoint
 	logging.Infof("registered HTTP client endpoint [%s]", newEndpoint.Name)
 
 	return nil
 }
 
-func (h *handler) SendAction(sendAction *commands.SendCommand) error {
-	endpoint, exists := h.endpoints[sendAction.EndpointName]
+func (h *handler) SendAction(sessionId string, variables *session.Variables, sendAction *commands.SendCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, sendAction.EndpointName)
 	if !exists {
-		h.logger.Errorf("HTTP client endpoint [%s] not found - action [%s] will not be executed",
+		return h.handleError("HTTP client endpoint [%s] not found - action [%s] will not be executed",
 			sendAction.EndpointName, sendAction.Name)
 	}
 
+	if sendAction.ResolvePlaceholders {
+		if err := utils.ResolvePlaceholders(variables, utils.SendFields{
+			Url:         &sendAction.Url,
+			Path:        sendAction.Path,
+			QueryParams: sendAction.QueryParams,
+			Headers:     sendAction.Headers,
+			Payload:     &sendAction.Payload,
+		}); err != nil {
+			return h.handleError("action [%s] will not be executed - %s", sendAction.Name, err)
+		}
+	}
+
 	return endpoint.Send(sendAction)
 }
 
-func (h *handler) ReceiveAction(receiveAction *commands.ReceiveCommand) (*http.Response, error) {
-	endpoint, exists := h.endpoints[receiveAction.EndpointName]
+// ReceiveAction saves the values extracted from the received response as session variables
+func (h *handler) ReceiveAction(sessionId string, variables *session.Variables,
+	receiveAction *commands.ReceiveCommand) (*commands.ReceivedResponse, map[string]string, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, receiveAction.EndpointName)
 	if !exists {
-		h.logger.Errorf("HTTP client endpoint [%s] not found - action [%s] will not be executed",
+		return nil, nil, h.handleError("HTTP client endpoint [%s] not found - action [%s] will not be executed",
 			receiveAction.EndpointName, receiveAction.Name)
 	}
 
-	return endpoint.Receive(receiveAction)
+	response, err := endpoint.Receive(receiveAction)
+	if response == nil {
+		return nil, nil, err
+	}
+
+	// responses have no path of their own
+	values, extractionErr := validators.ExtractValues(receiveAction.Extractions, receiveAction.Namespaces,
+		response.Headers, "", response.Payload, h.logger)
+	for name, value := range values {
+		variables.Set(name, value)
+	}
+
+	return response, values, errors.Join(err, extractionErr)
+}
+
+func (h *handler) handleError(format string, a ...any) error {
+	errorMessage := fmt.Sprintf(format, a...)
+	h.logger.Errorf(errorMessage)
+	return errors.New(errorMessage)
 }
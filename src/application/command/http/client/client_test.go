@@ -0,0 +1,46 @@
+package client
+
+import (
+	"github.com/go-clarum/agent/application/command/http/client/commands"
+	"github.com/go-clarum/agent/application/session"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPlaceholdersAreOnlyResolvedIfEnabled(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	t.Cleanup(server.Close)
+
+	s := session.NewSession("test")
+	s.Variables.Set("orderId", "42")
+	h := NewHttpClientHandler().(*handler)
+	t.Cleanup(func() { h.CloseSession(s.Id) })
+	if err := h.InitializeEndpoint(s.Id, &commands.InitEndpointCommand{Name: "client", BaseUrl: server.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	// shell & JavaScript templates in payloads are sent as they are
+	payload := `{"script": "echo ${HOME}", "id": "${orderId}"}`
+	if err := h.SendAction(s.Id, s.Variables, &commands.SendCommand{EndpointName: "client", Method: http.MethodPost,
+		Payload: payload}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+	if body := <-received; body != payload {
+		t.Errorf("expected the payload to be sent verbatim, but got [%s]", body)
+	}
+	_, _, _ = h.ReceiveAction(s.Id, s.Variables, &commands.ReceiveCommand{EndpointName: "client", StatusCode: 200})
+
+	if err := h.SendAction(s.Id, s.Variables, &commands.SendCommand{EndpointName: "client", Method: http.MethodPost,
+		Payload: `{"id": "${orderId}"}`, ResolvePlaceholders: true}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+	if body := <-received; body != `{"id": "42"}` {
+		t.Errorf("expected the placeholders to be resolved, but got [%s]", body)
+	}
+}
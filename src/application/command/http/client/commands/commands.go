@@ -12,8 +12,43 @@ type InitEndpointCommand struct {
 	BaseUrl        string
 	ContentType    string
 	TimeoutSeconds time.Duration
+	// Tls configures the HTTPS connections of the client, the system defaults are used if not set
+	Tls *TlsConfig
+	// Http2 enables HTTP/2, negotiated over TLS or with prior knowledge (h2c) for http urls
+	Http2 bool
 }
 
+// TlsConfig of the client. Relative file paths are resolved against the base directory of the agent.
+type TlsConfig struct {
+	// CaFile is a bundle of PEM encoded CA certificates, trusted in addition to the ones of the system
+	CaFile string
+	// TrustTestCa trusts the test CA of the agent, used by HTTP server endpoints with generated certificates
+	TrustTestCa bool
+	// CertFile & KeyFile are the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+	// GenerateClientCertificate issues a client certificate signed by the test CA of the agent
+	GenerateClientCertificate bool
+	// InsecureSkipVerify disables the verification of server certificates, a warning is logged outside the dev profile
+	InsecureSkipVerify bool
+	// ServerName overrides the name sent with SNI and used to verify the server certificate
+	ServerName string
+	// MinVersion of TLS, default 1.2
+	MinVersion TlsVersion
+}
+
+type TlsVersion int
+
+const (
+	TlsDefault TlsVersion = iota
+	Tls10
+	Tls11
+	Tls12
+	Tls13
+)
+
+// SendCommand may contain placeholders of session variables & template functions, like ${orderId} or ${uuid()},
+// in the url, path, query params, header values & payload.
 type SendCommand struct {
 	Name         string
 	Url          string
@@ -23,6 +58,11 @@ type SendCommand struct {
 	Headers      map[string]string
 	Payload      string
 	EndpointName string
+	// Binary replaces Payload for bodies that cannot be sent as strings
+	Binary *model.BinaryPayload
+	// ResolvePlaceholders replaces the placeholders of session variables & template functions in the Url, Path,
+	// QueryParams, Headers & Payload, like ${orderId} or ${uuid()}. The action is sent verbatim otherwise.
+	ResolvePlaceholders bool
 }
 
 type ReceiveCommand struct {
@@ -32,6 +72,48 @@ type ReceiveCommand struct {
 	Headers      map[string]string
 	Payload      string
 	EndpointName string
+	// Protocol of the response, for example "HTTP/2.0", not validated if empty
+	Protocol string
+	// Binary replaces Payload for bodies that cannot be validated as strings, or are validated by checksum & size
+	Binary *model.BinaryPayload
+	// XPath maps XPath expressions to the expected value of their result, evaluated on XML payloads
+	XPath map[string]string
+	// Namespaces binds the prefixes used in the XPath expressions to namespace URIs
+	Namespaces map[string]string
+	// JsonPath assertions validate single values of a JSON payload, in addition to or instead of the whole payload
+	JsonPath []model.JsonPathAssertion
+	// JsonSchema validates the JSON payload, draft 2020-12 or any draft selected with $schema, like draft-07.
+	// JsonSchemaFile is used instead if set, relative paths are resolved against the base directory of the agent.
+	JsonSchema     string
+	JsonSchemaFile string
+	// Extractions save values of the received message as session variables, also if its validation failed
+	Extractions []model.Extraction
+}
+
+type InitEndpointResult struct {
+	Error error
+}
+
+type SendResult struct {
+	Error error
+}
+
+type ReceiveResult struct {
+	// Response is set if a response was received, also if its validation failed
+	Response *ReceivedResponse
+	// Variables are the values saved by the extractions
+	Variables map[string]string
+	Error     error
+}
+
+// ReceivedResponse is the response taken by a receive action
+type ReceivedResponse struct {
+	StatusCode int
+	Protocol   string
+	Headers    map[string][]string
+	Payload    string
+	// PayloadTruncated is set if only the beginning of a large payload is returned
+	PayloadTruncated bool
 }
 
 func (action *SendCommand) ToString() string {
@@ -45,7 +127,7 @@ func (action *SendCommand) ToString() string {
 			"Payload: %s"+
 			"]",
 		action.Method, action.Url, action.Path,
-		action.Headers, action.QueryParams, action.Payload)
+		action.Headers, action.QueryParams, model.DescribePayload(action.Payload, action.Binary))
 }
 
 func (action *ReceiveCommand) ToString() string {
@@ -60,5 +142,19 @@ func (action *ReceiveCommand) ToString() string {
 			"Headers: %s, "+
 			"Payload: %s"+
 			"]",
-		statusCodeText, action.Headers, action.Payload)
+		statusCodeText, action.Headers, model.DescribePayload(action.Payload, action.Binary))
+}
+
+// ExpectedPayload returns everything the received payload is validated against
+func (action *ReceiveCommand) ExpectedPayload() *model.ExpectedPayload {
+	return &model.ExpectedPayload{
+		Payload:        action.Payload,
+		PayloadType:    action.PayloadType,
+		Binary:         action.Binary,
+		XPath:          action.XPath,
+		Namespaces:     action.Namespaces,
+		JsonPath:       action.JsonPath,
+		JsonSchema:     action.JsonSchema,
+		JsonSchemaFile: action.JsonSchemaFile,
+	}
 }
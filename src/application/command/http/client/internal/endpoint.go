@@ -2,10 +2,12 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/go-clarum/agent/application/command/http/client/commands"
 	"github.com/go-clarum/agent/application/command/http/common/constants"
+	"github.com/go-clarum/agent/application/command/http/common/model"
 	"github.com/go-clarum/agent/application/command/http/common/utils"
 	"github.com/go-clarum/agent/application/command/http/common/validators"
 	"github.com/go-clarum/agent/application/control"
@@ -15,14 +17,20 @@ import (
 	"github.com/go-clarum/agent/infrastructure/logging"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// maxLoggedPayloadSize is the size of the response payload kept in memory for logging, the rest is streamed
+const maxLoggedPayloadSize = 64 * 1024
+
 type Endpoint struct {
 	Name            string
 	baseUrl         string
 	contentType     string
 	client          *http.Client
+	context         context.Context
+	cancelContext   context.CancelFunc
 	responseChannel chan *responsePair
 	logger          *logging.Logger
 }
@@ -37,17 +45,28 @@ func NewEndpoint(ic *commands.InitEndpointCommand) (*Endpoint, error) {
 		return nil, errors.New("cannot create HTTP client endpoint - name is empty")
 	}
 
+	logger := logging.NewLogger(loggerName(ic.Name))
+	tlsConfig, err := newTlsConfig(ic.Tls, logger)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot create HTTP client endpoint [%s] - invalid TLS configuration - %s", ic.Name, err))
+	}
+
 	client := http.Client{
-		Timeout: durations.GetDurationWithDefault(ic.TimeoutSeconds, 10*time.Second),
+		Timeout:   durations.GetDurationWithDefault(ic.TimeoutSeconds, 10*time.Second),
+		Transport: newTransport(tlsConfig, ic.Http2),
 	}
 
+	ctx, cancelCtx := context.WithCancel(context.Background())
+
 	return &Endpoint{
 		Name:            ic.Name,
 		baseUrl:         ic.BaseUrl,
 		contentType:     ic.ContentType,
 		client:          &client,
+		context:         ctx,
+		cancelContext:   cancelCtx,
 		responseChannel: make(chan *responsePair),
-		logger:          logging.NewLogger(loggerName(ic.Name)),
+		logger:          logger,
 	}, nil
 }
 
@@ -75,7 +94,7 @@ func (endpoint *Endpoint) Send(action *commands.SendCommand) error {
 		control.RunningActions.Add(1)
 		defer control.RunningActions.Done()
 
-		endpoint.logOutgoingRequest(action.Payload, req)
+		endpoint.logOutgoingRequest(model.DescribePayload(action.Payload, action.Binary), req)
 		res, err := endpoint.client.Do(req)
 
 		// we log the error here directly, but will do error handling downstream
@@ -96,20 +115,31 @@ func (endpoint *Endpoint) Send(action *commands.SendCommand) error {
 		case endpoint.responseChannel <- responsePair:
 		case <-time.After(config.ActionTimeout()):
 			endpoint.handleError("action timed out - no client receive action called in test", nil)
+			closeBody(res)
+		case <-endpoint.context.Done():
+			endpoint.logger.Warn("response discarded - endpoint was closed")
+			closeBody(res)
 		}
 	}()
 
 	return nil
 }
 
+// Close cancels the actions still waiting on this endpoint and releases the idle connections of its HTTP client.
+func (endpoint *Endpoint) Close() {
+	endpoint.cancelContext()
+	endpoint.client.CloseIdleConnections()
+}
+
 func closeBody(res *http.Response) {
-	if res.Body != nil {
+	if res != nil && res.Body != nil {
 		res.Body.Close()
 	}
 }
 
-// validationOptions pass by value is intentional
-func (endpoint *Endpoint) Receive(action *commands.ReceiveCommand) (*http.Response, error) {
+// Receive validates the next response against the action. The received response is returned
+// also if its validation failed, so that values can be extracted from it.
+func (endpoint *Endpoint) Receive(action *commands.ReceiveCommand) (*commands.ReceivedResponse, error) {
 	if action == nil {
 		return nil, endpoint.handleError("receive action is nil", nil)
 	}
@@ -118,19 +148,31 @@ func (endpoint *Endpoint) Receive(action *commands.ReceiveCommand) (*http.Respon
 	select {
 	case responsePair := <-endpoint.responseChannel:
 		if responsePair.error != nil {
-			return responsePair.response, endpoint.handleError("error while receiving response", responsePair.error)
+			return nil, endpoint.handleError("error while receiving response", responsePair.error)
 		}
 
 		endpoint.enrichReceiveAction(action)
 		endpoint.logger.Debugf("validating receive action [%s]", action.ToString())
 
-		return responsePair.response, errors.Join(
-			validators.ValidateHttpStatusCode(action.StatusCode, responsePair.response.StatusCode, endpoint.logger),
-			validators.ValidateHttpHeaders(action.Headers, responsePair.response.Header, endpoint.logger),
-			validators.ValidateHttpPayload(&action.Payload, responsePair.response.Body,
-				action.PayloadType, endpoint.logger))
+		response := responsePair.response
+		body := utils.NewCapturedBody(response.Body, utils.MaxCapturedPayloadSize)
+		err := errors.Join(
+			validators.ValidateProtocol(action.Protocol, response.Proto, endpoint.logger),
+			validators.ValidateHttpStatusCode(action.StatusCode, response.StatusCode, endpoint.logger),
+			validators.ValidateHttpHeaders(action.Headers, response.Header, endpoint.logger),
+			validators.ValidateHttpBody(action.ExpectedPayload(), body, endpoint.logger))
+
+		return &commands.ReceivedResponse{
+			StatusCode:       response.StatusCode,
+			Protocol:         response.Proto,
+			Headers:          response.Header.Clone(),
+			Payload:          string(body.Bytes()),
+			PayloadTruncated: body.Truncated(),
+		}, err
 	case <-time.After(config.ActionTimeout()):
 		return nil, endpoint.handleError("receive action timed out - no response received for validation", nil)
+	case <-endpoint.context.Done():
+		return nil, endpoint.handleError("receive action canceled - endpoint was closed", nil)
 	}
 }
 
@@ -152,6 +194,11 @@ func (endpoint *Endpoint) enrichSendAction(action *commands.SendCommand) {
 
 // Put missing data into message to receive: ContentType Header
 func (endpoint *Endpoint) enrichReceiveAction(action *commands.ReceiveCommand) {
+	// if no Headers have been sent by the bindings, this will be nil
+	if action.Headers == nil {
+		action.Headers = make(map[string]string)
+	}
+
 	if clarumstrings.IsNotBlank(endpoint.contentType) {
 		if _, exists := action.Headers[constants.ContentTypeHeaderName]; !exists {
 			action.Headers[constants.ContentTypeHeaderName] = endpoint.contentType
@@ -169,6 +216,14 @@ func (endpoint *Endpoint) validateMessageToSend(action *commands.SendCommand) er
 	if !utils.IsValidUrl(action.Url) {
 		return endpoint.handleError("send action is invalid - invalid url", nil)
 	}
+	if action.Binary != nil {
+		if clarumstrings.IsNotBlank(action.Payload) {
+			return endpoint.handleError("send action is invalid - both a payload and a binary payload are set", nil)
+		}
+		if err := action.Binary.ValidateToSend(); err != nil {
+			return endpoint.handleError("send action is invalid", err)
+		}
+	}
 
 	return nil
 }
@@ -176,12 +231,20 @@ func (endpoint *Endpoint) validateMessageToSend(action *commands.SendCommand) er
 func (endpoint *Endpoint) buildRequest(action *commands.SendCommand) (*http.Request, error) {
 	url := utils.BuildPath(action.Url, action.Path...)
 
-	req, err := http.NewRequest(action.Method, url, bytes.NewBufferString(action.Payload))
+	body, size, err := requestBody(action)
 	if err != nil {
 		endpoint.logger.Errorf("error - %s", err)
 		return nil, err
 	}
 
+	req, err := http.NewRequestWithContext(endpoint.context, action.Method, url, body)
+	if err != nil {
+		endpoint.logger.Errorf("error - %s", err)
+		_ = body.Close()
+		return nil, err
+	}
+	req.ContentLength = size
+
 	for header, value := range action.Headers {
 		req.Header.Set(header, value)
 	}
@@ -197,6 +260,15 @@ func (endpoint *Endpoint) buildRequest(action *commands.SendCommand) (*http.Requ
 	return req, nil
 }
 
+// binary payloads from files are streamed, the request closes the file once it is sent
+func requestBody(action *commands.SendCommand) (io.ReadCloser, int64, error) {
+	if action.Binary != nil {
+		return action.Binary.Open()
+	}
+
+	return io.NopCloser(strings.NewReader(action.Payload)), int64(len(action.Payload)), nil
+}
+
 func (endpoint *Endpoint) handleError(message string, err error) error {
 	var errorMessage string
 	if err != nil {
@@ -218,18 +290,22 @@ func (endpoint *Endpoint) logOutgoingRequest(payload string, req *http.Request)
 		req.Method, req.URL, req.Header, payload)
 }
 
-// we read the body 'as is' for logging, after which we put it back into the response
-// with an open reader so that it can be read downstream again
+// we read the beginning of the body 'as is' for logging, after which we put it back in front of the rest
+// of the response body, so that it can be read downstream again. Large payloads are streamed this way.
 func (endpoint *Endpoint) logIncomingResponse(res *http.Response) {
-	bodyBytes, _ := io.ReadAll(res.Body)
+	preview := make([]byte, maxLoggedPayloadSize)
+	n, err := io.ReadFull(res.Body, preview)
+	truncated := err == nil
 	bodyString := ""
 
-	err := res.Body.Close()
-	if err != nil {
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
 		endpoint.logger.Errorf("could not read response body - %s", err)
 	} else {
-		res.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-		bodyString = string(bodyBytes)
+		bodyString = utils.LoggablePayload(preview[:n], truncated)
+	}
+	res.Body = &previewedBody{
+		Reader: io.MultiReader(bytes.NewReader(preview[:n]), res.Body),
+		Closer: res.Body,
 	}
 
 	endpoint.logger.Infof("received HTTP response ["+
@@ -240,6 +316,11 @@ func (endpoint *Endpoint) logIncomingResponse(res *http.Response) {
 		res.Status, res.Header, bodyString)
 }
 
+type previewedBody struct {
+	io.Reader
+	io.Closer
+}
+
 func loggerName(endpointName string) string {
 	return fmt.Sprintf("%s:", endpointName)
 }
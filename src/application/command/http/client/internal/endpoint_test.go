@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/go-clarum/agent/application/command/http/client/commands"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinaryFileRoundTrip(t *testing.T) {
+	// larger than the part of the payload that is logged
+	content := bytes.Repeat([]byte{0x25, 0x50, 0x44, 0x46, 0x00, 0xff}, 50_000)
+	file := filepath.Join(t.TempDir(), "upload.pdf")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(content)
+	size := int64(len(content))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != size {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := newTestEndpoint(t, server.URL)
+	if err := endpoint.Send(&commands.SendCommand{Method: http.MethodPost, Binary: &model.BinaryPayload{File: file}}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+
+	_, err := endpoint.Receive(&commands.ReceiveCommand{StatusCode: http.StatusOK, Binary: &model.BinaryPayload{
+		File:     file,
+		Checksum: "sha256:" + hex.EncodeToString(digest[:]),
+		Size:     &size,
+	}})
+	if err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+}
+
+func TestBinaryBytesMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte{0x01, 0x02, 0x03})
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := newTestEndpoint(t, server.URL)
+	if err := endpoint.Send(&commands.SendCommand{Method: http.MethodGet}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+
+	size := int64(3)
+	_, err := endpoint.Receive(&commands.ReceiveCommand{StatusCode: http.StatusOK,
+		Binary: &model.BinaryPayload{Bytes: []byte{0x01, 0x02, 0x04}, Size: &size}})
+	if err == nil || err.Error() != "validation error - payload mismatch at byte 2 - expected [0x04] but received [0x03]" {
+		t.Errorf("binary payload mismatch expected, but got %s", err)
+	}
+}
+
+func TestReceivedResponseIsReturned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := newTestEndpoint(t, server.URL)
+	if err := endpoint.Send(&commands.SendCommand{Method: http.MethodPost}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+
+	// no payload is expected, so the validation does not read the body
+	response, err := endpoint.Receive(&commands.ReceiveCommand{StatusCode: http.StatusOK})
+	if err == nil {
+		t.Errorf("status mismatch expected")
+	}
+	if response == nil {
+		t.Fatalf("expected the received response")
+	}
+	if response.StatusCode != http.StatusCreated || response.Protocol != "HTTP/1.1" {
+		t.Errorf("unexpected status %d or protocol [%s]", response.StatusCode, response.Protocol)
+	}
+	if values := response.Headers["Etag"]; len(values) != 1 || values[0] != `"v2"` {
+		t.Errorf("unexpected headers %v", response.Headers)
+	}
+	if response.Payload != `{"id": 42}` || response.PayloadTruncated {
+		t.Errorf("unexpected payload [%s]", response.Payload)
+	}
+}
+
+func TestReceivedPayloadIsTruncated(t *testing.T) {
+	content := bytes.Repeat([]byte("a"), 2*utils.MaxCapturedPayloadSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+
+	endpoint := newTestEndpoint(t, server.URL)
+	if err := endpoint.Send(&commands.SendCommand{Method: http.MethodGet}); err != nil {
+		t.Fatalf("no send error expected, but got %s", err)
+	}
+
+	size := int64(len(content))
+	response, err := endpoint.Receive(&commands.ReceiveCommand{StatusCode: http.StatusOK,
+		Binary: &model.BinaryPayload{Bytes: content, Size: &size}})
+	if err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+	if len(response.Payload) != utils.MaxCapturedPayloadSize || !response.PayloadTruncated {
+		t.Errorf("expected a truncated payload, but got %d bytes", len(response.Payload))
+	}
+}
+
+func TestInvalidBinaryPayloadToSend(t *testing.T) {
+	endpoint := newTestEndpoint(t, "http://localhost:8080")
+
+	err := endpoint.Send(&commands.SendCommand{Method: http.MethodPost,
+		Binary: &model.BinaryPayload{Bytes: []byte{0x01}, Checksum: "sha256:00"}})
+	if err == nil {
+		t.Errorf("expected error for a checksum in a payload to send")
+	}
+}
+
+func newTestEndpoint(t *testing.T, baseUrl string) *Endpoint {
+	endpoint, err := NewEndpoint(&commands.InitEndpointCommand{Name: "client", BaseUrl: baseUrl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(endpoint.Close)
+
+	return endpoint
+}
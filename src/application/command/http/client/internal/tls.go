@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/client/commands"
+	"github.com/go-clarum/agent/application/command/http/common/certificates"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"github.com/go-clarum/agent/infrastructure/logging"
+)
+
+const devProfile = "dev"
+
+var tlsVersions = map[commands.TlsVersion]uint16{
+	commands.TlsDefault: tls.VersionTLS12,
+	commands.Tls10:      tls.VersionTLS10,
+	commands.Tls11:      tls.VersionTLS11,
+	commands.Tls12:      tls.VersionTLS12,
+	commands.Tls13:      tls.VersionTLS13,
+}
+
+// newTlsConfig returns nil if the client uses the default TLS configuration
+func newTlsConfig(config *commands.TlsConfig, logger *logging.Logger) (*tls.Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	minVersion, exists := tlsVersions[config.MinVersion]
+	if !exists {
+		return nil, errors.New("unsupported minimum TLS version")
+	}
+
+	if config.InsecureSkipVerify && !isDevProfile() {
+		logger.Warn("server certificates are not verified - insecure skip verify is meant for the dev profile only")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	rootCAs, err := rootCAs(config)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.RootCAs = rootCAs
+
+	switch {
+	case config.GenerateClientCertificate:
+		ca, err := certificates.TestCA()
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := ca.IssueClientCertificate("clarum-agent")
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	case clarumstrings.IsNotBlank(config.CertFile) || clarumstrings.IsNotBlank(config.KeyFile):
+		certificate, err := certificates.LoadKeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// rootCAs returns nil if only the CAs of the system are trusted
+func rootCAs(config *commands.TlsConfig) (*x509.CertPool, error) {
+	if clarumstrings.IsBlank(config.CaFile) && !config.TrustTestCa {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if clarumstrings.IsNotBlank(config.CaFile) {
+		if err := certificates.AppendToCertPool(pool, config.CaFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.TrustTestCa {
+		ca, err := certificates.TestCA()
+		if err != nil {
+			return nil, err
+		}
+		pool.AddCert(ca.Certificate())
+	}
+
+	return pool, nil
+}
+
+func isDevProfile() bool {
+	return config.Profile() == devProfile
+}
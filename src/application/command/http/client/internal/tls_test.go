@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"crypto/tls"
+	"github.com/go-clarum/agent/application/command/http/client/commands"
+	"github.com/go-clarum/agent/application/command/http/common/certificates"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustTestCa(t *testing.T) {
+	server := startTlsServer(t, &tls.Config{})
+
+	if err := tlsGet(t, &commands.TlsConfig{TrustTestCa: true}, server.URL); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+	if err := tlsGet(t, &commands.TlsConfig{}, server.URL); err == nil {
+		t.Errorf("expected the test CA not to be trusted by default")
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	server := startTlsServer(t, &tls.Config{})
+
+	if err := tlsGet(t, &commands.TlsConfig{InsecureSkipVerify: true}, server.URL); err != nil {
+		t.Errorf("no error expected in the dev profile, but got %s", err)
+	}
+}
+
+func TestServerNameOverride(t *testing.T) {
+	server := startTlsServer(t, &tls.Config{})
+
+	err := tlsGet(t, &commands.TlsConfig{TrustTestCa: true, ServerName: "unknown.local"}, server.URL)
+	if err == nil {
+		t.Errorf("expected the server certificate not to be valid for the overridden server name")
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	server := startTlsServer(t, &tls.Config{MaxVersion: tls.VersionTLS12})
+
+	if err := tlsGet(t, &commands.TlsConfig{TrustTestCa: true, MinVersion: commands.Tls13}, server.URL); err == nil {
+		t.Errorf("expected the handshake to fail for a server without TLS 1.3")
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	ca, _ := certificates.TestCA()
+	server := startTlsServer(t, &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: ca.CertPool()})
+
+	if err := tlsGet(t, &commands.TlsConfig{TrustTestCa: true, GenerateClientCertificate: true}, server.URL); err != nil {
+		t.Errorf("no error expected with client certificate, but got %s", err)
+	}
+	if err := tlsGet(t, &commands.TlsConfig{TrustTestCa: true}, server.URL); err == nil {
+		t.Errorf("expected the request to fail without client certificate")
+	}
+}
+
+func TestInvalidTlsConfig(t *testing.T) {
+	_, err := NewEndpoint(&commands.InitEndpointCommand{
+		Name: "client",
+		Tls:  &commands.TlsConfig{CaFile: "missing.pem"},
+	})
+	if err == nil {
+		t.Errorf("error expected for missing CA file")
+	}
+}
+
+func startTlsServer(t *testing.T, tlsConfig *tls.Config) *httptest.Server {
+	ca, _ := certificates.TestCA()
+	certificate, err := ca.IssueServerCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	tlsConfig.Certificates = []tls.Certificate{certificate}
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func tlsGet(t *testing.T, config *commands.TlsConfig, url string) error {
+	endpoint, err := NewEndpoint(&commands.InitEndpointCommand{Name: "client", Tls: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer endpoint.Close()
+
+	response, err := endpoint.client.Get(url)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+)
+
+// newTransport returns a transport that only speaks HTTP/1.1, unless HTTP/2 is enabled
+func newTransport(tlsConfig *tls.Config, enableHttp2 bool) http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	if !enableHttp2 {
+		// an empty map disables the automatic HTTP/2 support of the transport
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		return transport
+	}
+
+	return &http2Transport{
+		tls: transport,
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			// h2c connections are not encrypted, even though the transport calls this to dial them
+			DialTLSContext: func(ctx context.Context, network string, addr string, _ *tls.Config) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+// http2Transport negotiates HTTP/2 over TLS for https urls and uses h2c with prior knowledge for http urls
+type http2Transport struct {
+	tls *http.Transport
+	h2c *http2.Transport
+}
+
+func (t *http2Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(request)
+	}
+
+	return t.tls.RoundTrip(request)
+}
+
+func (t *http2Transport) CloseIdleConnections() {
+	t.tls.CloseIdleConnections()
+	t.h2c.CloseIdleConnections()
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"crypto/tls"
+	"github.com/go-clarum/agent/application/command/http/client/commands"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestH2cTransport(t *testing.T) {
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(okHandler), &http2.Server{}))
+	t.Cleanup(server.Close)
+
+	if protocol := transportProtocol(t, &commands.InitEndpointCommand{Name: "client", Http2: true}, server.URL); protocol != "HTTP/2.0" {
+		t.Errorf("expected h2c, but got %s", protocol)
+	}
+	if protocol := transportProtocol(t, &commands.InitEndpointCommand{Name: "client"}, server.URL); protocol != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1 by default, but got %s", protocol)
+	}
+}
+
+func TestHttp2OverTlsTransport(t *testing.T) {
+	server := startTlsServer(t, &tls.Config{NextProtos: []string{"h2", "http/1.1"}})
+
+	http2Client := &commands.InitEndpointCommand{Name: "client", Http2: true, Tls: &commands.TlsConfig{TrustTestCa: true}}
+	if protocol := transportProtocol(t, http2Client, server.URL); protocol != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2 over TLS, but got %s", protocol)
+	}
+
+	http1Client := &commands.InitEndpointCommand{Name: "client", Tls: &commands.TlsConfig{TrustTestCa: true}}
+	if protocol := transportProtocol(t, http1Client, server.URL); protocol != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1 by default, but got %s", protocol)
+	}
+}
+
+func transportProtocol(t *testing.T, ic *commands.InitEndpointCommand, url string) string {
+	endpoint, err := NewEndpoint(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer endpoint.Close()
+
+	response, err := endpoint.client.Get(url)
+	if err != nil {
+		t.Fatalf("request failed - %s", err)
+	}
+	_ = response.Body.Close()
+
+	return response.Proto
+}
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
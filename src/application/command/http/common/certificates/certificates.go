@@ -0,0 +1,185 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const certificateValidity = 365 * 24 * time.Hour
+
+// CA is a certificate authority used to issue certificates for test endpoints.
+// The system under test only has to trust it once, for all the endpoints the agent starts.
+type CA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+var testCA *CA
+var testCAError error
+var testCAOnce sync.Once
+
+// TestCA returns the CA of the agent. It is generated on first use and lives as long as the agent.
+func TestCA() (*CA, error) {
+	testCAOnce.Do(func() {
+		testCA, testCAError = newCA()
+	})
+
+	return testCA, testCAError
+}
+
+func newCA() (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to generate CA key - %s", err))
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "Clarum Agent Test CA", Organization: []string{"go-clarum"}},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(certificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create CA certificate - %s", err))
+	}
+	certificate, _ := x509.ParseCertificate(der)
+
+	return &CA{
+		certificate: certificate,
+		key:         key,
+		pem:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// PEM returns the certificate of the CA, PEM encoded
+func (ca *CA) PEM() string {
+	return string(ca.pem)
+}
+
+// CertPool returns a pool that trusts only this CA
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.certificate)
+	return pool
+}
+
+// Certificate returns the certificate of the CA
+func (ca *CA) Certificate() *x509.Certificate {
+	return ca.certificate
+}
+
+// Export writes the certificate of the CA to the given file, relative paths are resolved against the base directory
+func (ca *CA) Export(file string) error {
+	if err := os.WriteFile(config.ResolvePath(file), ca.pem, 0644); err != nil {
+		return errors.New(fmt.Sprintf("unable to export CA certificate - %s", err))
+	}
+
+	return nil
+}
+
+// IssueServerCertificate creates a certificate for localhost and the given hosts, which may be DNS names or IPs
+func (ca *CA) IssueServerCertificate(hosts []string) (tls.Certificate, error) {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost", Organization: []string{"go-clarum"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return ca.issue(template)
+}
+
+// IssueClientCertificate creates a certificate that clients use to authenticate with mutual TLS
+func (ca *CA) IssueClientCertificate(commonName string) (tls.Certificate, error) {
+	return ca.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName, Organization: []string{"go-clarum"}},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+func (ca *CA) issue(template *x509.Certificate) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("unable to generate certificate key - %s", err))
+	}
+
+	template.SerialNumber = serialNumber()
+	template.NotBefore = time.Now().Add(-1 * time.Hour)
+	template.NotAfter = time.Now().Add(certificateValidity)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("unable to create certificate - %s", err))
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der, ca.certificate.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+// LoadKeyPair loads a PEM encoded certificate & key, relative paths are resolved against the base directory
+func LoadKeyPair(certFile string, keyFile string) (tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(config.ResolvePath(certFile), config.ResolvePath(keyFile))
+	if err != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("unable to load certificate - %s", err))
+	}
+
+	return certificate, nil
+}
+
+// LoadCertPool loads the PEM encoded CA certificates of the file, relative paths are resolved against the base directory
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if err := AppendToCertPool(pool, caFile); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// AppendToCertPool adds the PEM encoded CA certificates of the file to the pool
+func AppendToCertPool(pool *x509.CertPool, caFile string) error {
+	content, err := os.ReadFile(config.ResolvePath(caFile))
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read CA certificates - %s", err))
+	}
+
+	if !pool.AppendCertsFromPEM(content) {
+		return errors.New(fmt.Sprintf("no CA certificates found in [%s]", caFile))
+	}
+
+	return nil
+}
+
+func serialNumber() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
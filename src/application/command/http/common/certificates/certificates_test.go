@@ -0,0 +1,66 @@
+package certificates
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+)
+
+func TestTestCAIsShared(t *testing.T) {
+	first, err := TestCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := TestCA()
+
+	if first != second {
+		t.Errorf("expected the same test CA for the whole agent")
+	}
+}
+
+func TestIssueServerCertificate(t *testing.T) {
+	ca, _ := TestCA()
+
+	certificate, err := ca.IssueServerCertificate([]string{"sut.local", "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(certificate.Certificate[0])
+
+	for _, host := range []string{"localhost", "127.0.0.1", "sut.local", "10.0.0.1"} {
+		_, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: ca.CertPool()})
+		if err != nil {
+			t.Errorf("expected certificate to be valid for %s - %s", host, err)
+		}
+	}
+}
+
+func TestIssueClientCertificate(t *testing.T) {
+	ca, _ := TestCA()
+
+	certificate, err := ca.IssueClientCertificate("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(certificate.Certificate[0])
+
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: ca.CertPool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Errorf("expected client certificate to be valid - %s", err)
+	}
+}
+
+func TestExportAndLoadCertPool(t *testing.T) {
+	ca, _ := TestCA()
+	file := filepath.Join(t.TempDir(), "ca.pem")
+
+	if err := ca.Export(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCertPool(file); err != nil {
+		t.Errorf("expected exported CA to be loaded - %s", err)
+	}
+	if _, err := LoadCertPool(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Errorf("error expected for missing file")
+	}
+}
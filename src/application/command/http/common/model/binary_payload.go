@@ -0,0 +1,90 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"io"
+	"os"
+)
+
+// BinaryPayload replaces the textual payload of an action, for bodies that cannot round-trip through strings.
+// The content is either Bytes or a File, relative paths are resolved against the base directory of the agent.
+// Files are streamed, so they are never completely loaded into memory.
+type BinaryPayload struct {
+	Bytes []byte
+	File  string
+	// Checksum & Size are only used to validate received payloads, so that large payloads can be validated
+	// without the expected content. The checksum has the form "<algorithm>:<hex digest>", for example "sha256:9f86d0...",
+	// with sha256, sha512, sha1 or md5 as algorithm.
+	Checksum string
+	Size     *int64
+}
+
+// HasContent is true if the payload has an expected content to compare with, not just a checksum or size
+func (payload *BinaryPayload) HasContent() bool {
+	return len(payload.Bytes) > 0 || clarumstrings.IsNotBlank(payload.File)
+}
+
+// Open returns the content of the payload and its size
+func (payload *BinaryPayload) Open() (io.ReadCloser, int64, error) {
+	if clarumstrings.IsBlank(payload.File) {
+		return io.NopCloser(bytes.NewReader(payload.Bytes)), int64(len(payload.Bytes)), nil
+	}
+
+	file, err := os.Open(config.ResolvePath(payload.File))
+	if err != nil {
+		return nil, 0, errors.New(fmt.Sprintf("unable to open payload file - %s", err))
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, errors.New(fmt.Sprintf("unable to read payload file - %s", err))
+	}
+
+	return file, info.Size(), nil
+}
+
+// ValidateToSend checks that the payload can be sent: it has a single content, which is available
+func (payload *BinaryPayload) ValidateToSend() error {
+	if len(payload.Bytes) > 0 && clarumstrings.IsNotBlank(payload.File) {
+		return errors.New("binary payload has both bytes and a file")
+	}
+	if clarumstrings.IsNotBlank(payload.Checksum) || payload.Size != nil {
+		return errors.New("checksum and size can only be used to validate received payloads")
+	}
+	if clarumstrings.IsNotBlank(payload.File) {
+		if _, err := os.Stat(config.ResolvePath(payload.File)); err != nil {
+			return errors.New(fmt.Sprintf("payload file is not readable - %s", err))
+		}
+	}
+
+	return nil
+}
+
+func (payload *BinaryPayload) String() string {
+	if clarumstrings.IsNotBlank(payload.File) {
+		return fmt.Sprintf("[file: %s]", payload.File)
+	}
+	if len(payload.Bytes) > 0 {
+		return fmt.Sprintf("[binary: %d bytes]", len(payload.Bytes))
+	}
+	return fmt.Sprintf("[checksum: %s, size: %s]", payload.Checksum, sizeText(payload.Size))
+}
+
+// DescribePayload returns the payload of an action for logging
+func DescribePayload(payload string, binary *BinaryPayload) string {
+	if binary != nil {
+		return binary.String()
+	}
+	return payload
+}
+
+func sizeText(size *int64) string {
+	if size == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d", *size)
+}
@@ -0,0 +1,18 @@
+package model
+
+// ExpectedPayload groups everything a received payload is validated against
+type ExpectedPayload struct {
+	Payload     string
+	PayloadType PayloadType
+	// Binary replaces Payload for bodies that cannot be validated as strings
+	Binary *BinaryPayload
+	// XPath maps XPath expressions to the expected value of their result, evaluated on XML payloads
+	XPath map[string]string
+	// Namespaces binds the prefixes used in the XPath expressions to namespace URIs
+	Namespaces map[string]string
+	// JsonPath assertions are evaluated on JSON payloads, in addition to or instead of comparing the whole payload
+	JsonPath []JsonPathAssertion
+	// JsonSchema is a schema the JSON payload must conform to, given inline or as a file
+	JsonSchema     string
+	JsonSchemaFile string
+}
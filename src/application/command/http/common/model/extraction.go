@@ -0,0 +1,41 @@
+package model
+
+// Extraction saves a value of a received message as a session variable, so that later actions can reference it.
+type Extraction struct {
+	Variable string
+	Source   ExtractionSource
+	// Expression depends on the source: a JSONPath or XPath expression, a header name, a regular expression
+	// or the index of a path segment
+	Expression string
+}
+
+type ExtractionSource int
+
+const (
+	// JsonPathSource saves the value selected in a JSON payload, arrays & objects are saved as JSON
+	JsonPathSource ExtractionSource = iota
+	// XPathSource saves the string value of the result, for node sets the value of the first node
+	XPathSource
+	// HeaderSource saves the first value of the header, header names are case-insensitive
+	HeaderSource
+	// BodyRegexSource saves the first capturing group of the first match in the payload, the whole match if there is no group
+	BodyRegexSource
+	// PathSegmentSource saves a segment of the request path, negative indexes count from the end.
+	// Only received requests have a path.
+	PathSegmentSource
+)
+
+func (s ExtractionSource) String() string {
+	switch s {
+	case XPathSource:
+		return "xpath"
+	case HeaderSource:
+		return "header"
+	case BodyRegexSource:
+		return "body regex"
+	case PathSegmentSource:
+		return "path segment"
+	default:
+		return "jsonpath"
+	}
+}
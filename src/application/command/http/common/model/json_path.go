@@ -0,0 +1,43 @@
+package model
+
+// JsonPathAssertion validates the values a JSONPath expression selects in a JSON payload.
+// Expressions with wildcards, slices or recursive descent select an array of all matching values.
+type JsonPathAssertion struct {
+	Path    string
+	Matcher JsonPathMatcher
+	// Expected depends on the matcher: a value, a regular expression, a type or a size
+	Expected string
+}
+
+type JsonPathMatcher int
+
+const (
+	// Equals compares the selected value with the expected JSON value, strings may also be given without quotes
+	Equals JsonPathMatcher = iota
+	// Regex matches the whole string representation of the selected value
+	Regex
+	// Exists only checks that the expression selects a value, NotExists that it selects none
+	Exists
+	NotExists
+	// Type is one of string, number, boolean, object, array or null
+	Type
+	// Size is the number of elements of an array, the number of keys of an object or the length of a string
+	Size
+)
+
+func (m JsonPathMatcher) String() string {
+	switch m {
+	case Regex:
+		return "regex"
+	case Exists:
+		return "exists"
+	case NotExists:
+		return "not exists"
+	case Type:
+		return "type"
+	case Size:
+		return "size"
+	default:
+		return "equals"
+	}
+}
@@ -5,4 +5,5 @@ type PayloadType int
 const (
 	Plaintext PayloadType = iota
 	Json
+	Xml
 )
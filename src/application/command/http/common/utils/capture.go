@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+)
+
+// MaxCapturedPayloadSize is the size of the payload kept in memory for receive results, request matching
+// and the journal. Larger payloads are streamed, only their beginning is captured.
+const MaxCapturedPayloadSize = 1024 * 1024
+
+// Capture keeps the beginning of the data added to it, up to the limit
+type Capture struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func NewCapture(limit int) *Capture {
+	return &Capture{limit: limit}
+}
+
+func (c *Capture) Add(data []byte) {
+	if c.truncated {
+		return
+	}
+	if remaining := c.limit - len(c.data); len(data) > remaining {
+		c.data = append(c.data, data[:remaining]...)
+		c.truncated = true
+	} else {
+		c.data = append(c.data, data...)
+	}
+}
+
+func (c *Capture) Bytes() []byte {
+	return c.data
+}
+
+// Truncated is set once more data was added than the limit allows
+func (c *Capture) Truncated() bool {
+	return c.truncated
+}
+
+// CapturedBody keeps the beginning of the body read by the validation, up to the limit.
+// The validation may not read the body at all, so the part needed to fill the capture is read on close.
+type CapturedBody struct {
+	*Capture
+	body io.ReadCloser
+}
+
+func NewCapturedBody(body io.ReadCloser, limit int) *CapturedBody {
+	return &CapturedBody{Capture: NewCapture(limit), body: body}
+}
+
+func (b *CapturedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.Add(p[:n])
+	return n, err
+}
+
+func (b *CapturedBody) Close() error {
+	if !b.truncated {
+		// one byte more than the limit tells us whether the payload is truncated
+		_, _ = io.Copy(io.Discard, io.LimitReader(b, int64(b.limit-len(b.data)+1)))
+	}
+	return b.body.Close()
+}
+
+// PreviewBody reads the beginning of the body up to the limit, and returns it together with a body
+// that still contains all of it. The rest of the body is not read, so that it can be streamed downstream.
+func PreviewBody(body io.ReadCloser, limit int) (*Capture, io.ReadCloser, error) {
+	// one byte more than the limit tells us whether the payload is truncated
+	preview, err := io.ReadAll(io.LimitReader(body, int64(limit+1)))
+	if err != nil {
+		return nil, body, err
+	}
+
+	capture := NewCapture(limit)
+	capture.Add(preview)
+
+	return capture, &previewedBody{
+		Reader: io.MultiReader(bytes.NewReader(preview), body),
+		Closer: body,
+	}, nil
+}
+
+type previewedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestPreviewBody(t *testing.T) {
+	content := []byte("0123456789")
+
+	capture, body, err := PreviewBody(io.NopCloser(bytes.NewReader(content)), 4)
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	if string(capture.Bytes()) != "0123" || !capture.Truncated() {
+		t.Errorf("unexpected preview [%s]", capture.Bytes())
+	}
+	if all, _ := io.ReadAll(body); !bytes.Equal(all, content) {
+		t.Errorf("expected the whole body to be readable, but got [%s]", all)
+	}
+
+	capture, _, _ = PreviewBody(io.NopCloser(bytes.NewReader(content)), len(content))
+	if string(capture.Bytes()) != string(content) || capture.Truncated() {
+		t.Errorf("expected a body of the size of the limit not to be truncated")
+	}
+}
+
+func TestCapturedBodyIsFilledOnClose(t *testing.T) {
+	body := NewCapturedBody(io.NopCloser(bytes.NewReader([]byte("0123456789"))), 4)
+
+	_ = body.Close()
+	if string(body.Bytes()) != "0123" || !body.Truncated() {
+		t.Errorf("unexpected capture [%s]", body.Bytes())
+	}
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// LoggablePayload returns the payload as text, or a summary if it is binary. A truncated payload
+// is only the beginning of the body, in which case the last character may have been cut.
+// Payloads that are not valid UTF-8 or contain NUL bytes are considered binary.
+func LoggablePayload(payload []byte, truncated bool) string {
+	text := payload
+	if truncated {
+		text = trimIncompleteRune(text)
+	}
+
+	if !utf8.Valid(text) || bytes.IndexByte(text, 0) >= 0 {
+		if truncated {
+			return fmt.Sprintf("[binary: more than %d bytes]", len(payload))
+		}
+		return fmt.Sprintf("[binary: %d bytes]", len(payload))
+	}
+	if truncated {
+		return string(text) + "... [truncated]"
+	}
+	return string(text)
+}
+
+func trimIncompleteRune(text []byte) []byte {
+	for i := len(text) - 1; i >= 0 && i >= len(text)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(text[i]) {
+			if !utf8.FullRune(text[i:]) {
+				return text[:i]
+			}
+			break
+		}
+	}
+
+	return text
+}
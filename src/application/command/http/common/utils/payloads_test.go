@@ -0,0 +1,22 @@
+package utils
+
+import "testing"
+
+func TestLoggableTextPayload(t *testing.T) {
+	if text := LoggablePayload([]byte("größe"), false); text != "größe" {
+		t.Errorf("Unexpected loggable payload %s", text)
+	}
+	// the last character is cut in half
+	if text := LoggablePayload([]byte("größe")[:5], true); text != "grö... [truncated]" {
+		t.Errorf("Unexpected loggable payload %s", text)
+	}
+}
+
+func TestLoggableBinaryPayload(t *testing.T) {
+	if text := LoggablePayload([]byte{0x25, 0x00, 0xff, 0xfe}, false); text != "[binary: 4 bytes]" {
+		t.Errorf("Unexpected loggable payload %s", text)
+	}
+	if text := LoggablePayload([]byte{0x25, 0x00, 0xff, 0xfe}, true); text != "[binary: more than 4 bytes]" {
+		t.Errorf("Unexpected loggable payload %s", text)
+	}
+}
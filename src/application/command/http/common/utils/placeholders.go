@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"github.com/go-clarum/agent/application/session"
+)
+
+// SendFields are the fields of a send action that may contain placeholders. They are resolved in place,
+// fields a send action does not have are left empty.
+type SendFields struct {
+	Url         *string
+	Path        []string
+	QueryParams map[string][]string
+	Headers     map[string]string
+	Payload     *string
+}
+
+// ResolvePlaceholders replaces the placeholders of session variables & template functions in the fields
+func ResolvePlaceholders(variables *session.Variables, fields SendFields) error {
+	var err error
+	if fields.Url != nil {
+		if *fields.Url, err = variables.Resolve(*fields.Url); err != nil {
+			return err
+		}
+	}
+	if err = resolveAll(variables, fields.Path); err != nil {
+		return err
+	}
+	for _, values := range fields.QueryParams {
+		if err = resolveAll(variables, values); err != nil {
+			return err
+		}
+	}
+	for header, value := range fields.Headers {
+		if fields.Headers[header], err = variables.Resolve(value); err != nil {
+			return err
+		}
+	}
+	if fields.Payload != nil {
+		*fields.Payload, err = variables.Resolve(*fields.Payload)
+	}
+
+	return err
+}
+
+func resolveAll(variables *session.Variables, texts []string) error {
+	for i, text := range texts {
+		resolved, err := variables.Resolve(text)
+		if err != nil {
+			return err
+		}
+		texts[i] = resolved
+	}
+
+	return nil
+}
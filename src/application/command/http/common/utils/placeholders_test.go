@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"github.com/go-clarum/agent/application/session"
+	"testing"
+)
+
+func TestResolvePlaceholders(t *testing.T) {
+	variables := session.NewVariables()
+	variables.Set("orderId", "42")
+
+	url := "http://${orderId}.example.com"
+	payload := `{"id": ${orderId}}`
+	fields := SendFields{
+		Url:         &url,
+		Path:        []string{"orders", "${orderId}"},
+		QueryParams: map[string][]string{"id": {"${orderId}"}},
+		Headers:     map[string]string{"If-Match": "${base64(${orderId})}"},
+		Payload:     &payload,
+	}
+
+	if err := ResolvePlaceholders(variables, fields); err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	if url != "http://42.example.com" || fields.Path[1] != "42" || fields.QueryParams["id"][0] != "42" ||
+		fields.Headers["If-Match"] != "NDI=" || payload != `{"id": 42}` {
+		t.Errorf("unexpected resolved fields %+v - url [%s], payload [%s]", fields, url, payload)
+	}
+}
+
+func TestResolvePlaceholdersError(t *testing.T) {
+	payload := "${orderId}"
+
+	err := ResolvePlaceholders(session.NewVariables(), SendFields{Payload: &payload})
+	if err == nil || err.Error() != "variable <orderId> is not set" {
+		t.Errorf("expected unset variable error, but got %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
 	"net/url"
+	"path"
+	"strings"
 )
 
 func IsValidUrl(urlToCheck string) bool {
@@ -21,3 +23,9 @@ func BuildPath(base string, pathElements ...string) string {
 		return path
 	}
 }
+
+// CleanPath returns the shortest path equivalent to the given one.
+// path.Clean() does not remove leading "/", so we do that ourselves
+func CleanPath(pathToClean string) string {
+	return strings.TrimPrefix(path.Clean(pathToClean), "/")
+}
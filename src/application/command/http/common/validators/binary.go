@@ -0,0 +1,148 @@
+package validators
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ValidateHttpBinaryPayload streams the received payload through all the criteria set in the expected payload:
+// byte by byte comparison with the expected content, checksum & size. The payload is never completely in memory.
+func ValidateHttpBinaryPayload(expectedPayload *model.BinaryPayload, actualPayload io.ReadCloser,
+	logger *logging.Logger) error {
+	defer closeBody(logger, actualPayload)
+
+	algorithm, checksum, err := newChecksum(expectedPayload.Checksum)
+	if err != nil {
+		return handleError(logger, "validation error - %s", err)
+	}
+
+	comparison := &comparingWriter{}
+	if expectedPayload.HasContent() {
+		expectedContent, _, err := expectedPayload.Open()
+		if err != nil {
+			return handleError(logger, "validation error - %s", err)
+		}
+		defer expectedContent.Close()
+		comparison.expected = expectedContent
+	}
+
+	writers := []io.Writer{comparison}
+	if checksum != nil {
+		writers = append(writers, checksum)
+	}
+
+	size, err := io.Copy(io.MultiWriter(writers...), actualPayload)
+	if err != nil {
+		return handleError(logger, "could not read payload - %s", err)
+	}
+
+	var errs []error
+	if err := comparison.result(size); err != nil {
+		errs = append(errs, err)
+	}
+	if expectedPayload.Size != nil && *expectedPayload.Size != size {
+		errs = append(errs, errors.New(fmt.Sprintf("validation error - payload size mismatch - expected [%d] but received [%d]",
+			*expectedPayload.Size, size)))
+	}
+	if checksum != nil {
+		actualChecksum := algorithm + ":" + hex.EncodeToString(checksum.Sum(nil))
+		if !strings.EqualFold(actualChecksum, strings.TrimSpace(expectedPayload.Checksum)) {
+			errs = append(errs, errors.New(fmt.Sprintf("validation error - payload checksum mismatch - expected [%s] but received [%s]",
+				expectedPayload.Checksum, actualChecksum)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return handleError(logger, "%s", errors.Join(errs...))
+	}
+	logger.Infof("binary payload validation successful - received %d bytes", size)
+
+	return nil
+}
+
+func newChecksum(checksum string) (string, hash.Hash, error) {
+	if clarumstrings.IsBlank(checksum) {
+		return "", nil, nil
+	}
+
+	algorithm, _, found := strings.Cut(strings.TrimSpace(checksum), ":")
+	if !found {
+		return "", nil, errors.New(fmt.Sprintf("invalid checksum [%s] - expected <algorithm>:<hex digest>", checksum))
+	}
+
+	algorithm = strings.ToLower(algorithm)
+	switch algorithm {
+	case "sha256":
+		return algorithm, sha256.New(), nil
+	case "sha512":
+		return algorithm, sha512.New(), nil
+	case "sha1":
+		return algorithm, sha1.New(), nil
+	case "md5":
+		return algorithm, md5.New(), nil
+	default:
+		return "", nil, errors.New(fmt.Sprintf("unsupported checksum algorithm [%s]", algorithm))
+	}
+}
+
+// comparingWriter compares everything written to it with the expected content, up to the first difference
+type comparingWriter struct {
+	expected io.Reader
+	offset   int64
+	mismatch error
+	buffer   []byte
+}
+
+func (w *comparingWriter) Write(p []byte) (int, error) {
+	if w.expected == nil || w.mismatch != nil {
+		return len(p), nil
+	}
+
+	if cap(w.buffer) < len(p) {
+		w.buffer = make([]byte, len(p))
+	}
+	expected := w.buffer[:len(p)]
+	n, _ := io.ReadFull(w.expected, expected)
+
+	for i := 0; i < n; i++ {
+		if p[i] != expected[i] {
+			w.mismatch = errors.New(fmt.Sprintf("validation error - payload mismatch at byte %d - expected [%#02x] but received [%#02x]",
+				w.offset+int64(i), expected[i], p[i]))
+			return len(p), nil
+		}
+	}
+	if n < len(p) {
+		w.mismatch = errors.New(fmt.Sprintf("validation error - payload mismatch - expected %d bytes but received more",
+			w.offset+int64(n)))
+		return len(p), nil
+	}
+
+	w.offset += int64(n)
+	return len(p), nil
+}
+
+// result checks that the expected content was completely received
+func (w *comparingWriter) result(size int64) error {
+	if w.expected == nil || w.mismatch != nil {
+		return w.mismatch
+	}
+
+	remaining, _ := io.Copy(io.Discard, w.expected)
+	if remaining > 0 {
+		return errors.New(fmt.Sprintf("validation error - payload mismatch - expected %d bytes but received %d",
+			size+remaining, size))
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package validators
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var binaryContent = []byte{0x25, 0x50, 0x44, 0x46, 0x00, 0xff, 0xfe, 0x0a, 0x80}
+
+func TestValidateBinaryPayloadBytes(t *testing.T) {
+	expected := &model.BinaryPayload{Bytes: binaryContent}
+
+	if err := ValidateHttpBinaryPayload(expected, binaryBody(binaryContent), logger); err != nil {
+		t.Errorf("No binary payload validation error expected, but got %s", err)
+	}
+}
+
+func TestValidateBinaryPayloadMismatch(t *testing.T) {
+	expected := &model.BinaryPayload{Bytes: binaryContent}
+	actual := bytes.Clone(binaryContent)
+	actual[5] = 0x00
+
+	err := ValidateHttpBinaryPayload(expected, binaryBody(actual), logger)
+
+	if err == nil || err.Error() != "validation error - payload mismatch at byte 5 - expected [0xff] but received [0x00]" {
+		t.Errorf("Binary payload mismatch error expected, but got %s", err)
+	}
+}
+
+func TestValidateBinaryPayloadLength(t *testing.T) {
+	expected := &model.BinaryPayload{Bytes: binaryContent}
+
+	err := ValidateHttpBinaryPayload(expected, binaryBody(binaryContent[:4]), logger)
+	if err == nil || err.Error() != "validation error - payload mismatch - expected 9 bytes but received 4" {
+		t.Errorf("Shorter payload error expected, but got %s", err)
+	}
+
+	err = ValidateHttpBinaryPayload(expected, binaryBody(append(bytes.Clone(binaryContent), 0x01)), logger)
+	if err == nil || err.Error() != "validation error - payload mismatch - expected 9 bytes but received more" {
+		t.Errorf("Longer payload error expected, but got %s", err)
+	}
+}
+
+func TestValidateBinaryPayloadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "expected.pdf")
+	if err := os.WriteFile(file, binaryContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateHttpBinaryPayload(&model.BinaryPayload{File: file}, binaryBody(binaryContent), logger); err != nil {
+		t.Errorf("No binary payload validation error expected, but got %s", err)
+	}
+}
+
+func TestValidateBinaryPayloadChecksumAndSize(t *testing.T) {
+	// large enough to be read in several parts
+	content := bytes.Repeat(binaryContent, 100_000)
+	digest := sha256.Sum256(content)
+	size := int64(len(content))
+
+	expected := &model.BinaryPayload{Checksum: "SHA256:" + hex.EncodeToString(digest[:]), Size: &size}
+	if err := ValidateHttpBinaryPayload(expected, binaryBody(content), logger); err != nil {
+		t.Errorf("No binary payload validation error expected, but got %s", err)
+	}
+
+	wrongSize := size - 1
+	expected = &model.BinaryPayload{Checksum: "sha256:00", Size: &wrongSize}
+	err := ValidateHttpBinaryPayload(expected, binaryBody(content), logger)
+	if err == nil || !strings.Contains(err.Error(), "payload size mismatch - expected [899999] but received [900000]") ||
+		!strings.Contains(err.Error(), "payload checksum mismatch - expected [sha256:00]") {
+		t.Errorf("Size & checksum errors expected, but got %s", err)
+	}
+}
+
+func TestValidateBinaryPayloadInvalidChecksum(t *testing.T) {
+	err := ValidateHttpBinaryPayload(&model.BinaryPayload{Checksum: "crc32:1234"}, binaryBody(binaryContent), logger)
+
+	if err == nil || err.Error() != "validation error - unsupported checksum algorithm [crc32]" {
+		t.Errorf("Invalid checksum error expected, but got %s", err)
+	}
+}
+
+func TestValidateHttpBodyWithPayloadAndBinary(t *testing.T) {
+	expected := &model.ExpectedPayload{Payload: "text", Binary: &model.BinaryPayload{Bytes: binaryContent}}
+
+	err := ValidateHttpBody(expected, binaryBody(binaryContent), logger)
+	if err == nil {
+		t.Errorf("Error expected for a payload and a binary payload")
+	}
+}
+
+func binaryBody(content []byte) io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(content))
+}
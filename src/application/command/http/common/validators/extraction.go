@@ -0,0 +1,114 @@
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExtractValues evaluates the extractions on a received message & returns the values by variable name.
+// Every extraction that finds no value is reported, the values found are returned nevertheless.
+func ExtractValues(extractions []model.Extraction, namespaces map[string]string, headers map[string][]string,
+	path string, payload string, logger *logging.Logger) (map[string]string, error) {
+	if len(extractions) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]string)
+	var errs []error
+	for _, extraction := range extractions {
+		value, err := extractValue(extraction, namespaces, headers, path, payload)
+		if err != nil {
+			errs = append(errs, handleError(logger, "extraction error - variable <%s> - %s <%s> %s",
+				extraction.Variable, extraction.Source, extraction.Expression, err))
+			continue
+		}
+
+		logger.Debugf("extracted variable <%s> = [%s]", extraction.Variable, value)
+		values[extraction.Variable] = value
+	}
+
+	return values, errors.Join(errs...)
+}
+
+func extractValue(extraction model.Extraction, namespaces map[string]string, headers map[string][]string,
+	path string, payload string) (string, error) {
+	switch extraction.Source {
+	case model.XPathSource:
+		document, err := parseXml([]byte(payload))
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("- received payload is %s", err))
+		}
+		value, found, err := evaluateXPath(extraction.Expression, namespaces, document)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("- %s", err))
+		} else if !found {
+			return "", errors.New("- no node found")
+		}
+		return value, nil
+	case model.HeaderSource:
+		for header, values := range headers {
+			if strings.EqualFold(header, extraction.Expression) && len(values) > 0 {
+				return values[0], nil
+			}
+		}
+		return "", errors.New("- header missing")
+	case model.BodyRegexSource:
+		pattern, err := regexp.Compile(extraction.Expression)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("- invalid regex - %s", err))
+		}
+		match := pattern.FindStringSubmatch(payload)
+		if match == nil {
+			return "", errors.New("- no match found")
+		} else if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	case model.PathSegmentSource:
+		return pathSegment(extraction.Expression, path)
+	default:
+		var document any
+		if err := json.Unmarshal([]byte(payload), &document); err != nil {
+			return "", errors.New(fmt.Sprintf("- received payload is invalid json - %s", err))
+		}
+		evaluable, err := jsonpath.New(extraction.Expression)
+		if err != nil {
+			return "", errors.New(fmt.Sprintf("- invalid expression - %s", err))
+		}
+		value, err := evaluable(context.Background(), document)
+		if err != nil {
+			return "", errors.New("- no value found")
+		}
+		return jsonText(value), nil
+	}
+}
+
+func pathSegment(expression string, path string) (string, error) {
+	index, err := strconv.Atoi(strings.TrimSpace(expression))
+	if err != nil {
+		return "", errors.New("- index is not a number")
+	}
+
+	cleanedPath := utils.CleanPath(path)
+	if cleanedPath == "." || cleanedPath == "" {
+		return "", errors.New("- no path received")
+	}
+	segments := strings.Split(cleanedPath, "/")
+	if index < 0 {
+		index += len(segments)
+	}
+	if index < 0 || index >= len(segments) {
+		return "", errors.New(fmt.Sprintf("- index out of bounds of path [%s]", path))
+	}
+
+	return segments[index], nil
+}
@@ -0,0 +1,73 @@
+package validators
+
+import (
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"testing"
+)
+
+func TestExtractValues(t *testing.T) {
+	extractions := []model.Extraction{
+		{Variable: "orderId", Source: model.JsonPathSource, Expression: "$.order.id"},
+		{Variable: "items", Source: model.JsonPathSource, Expression: "$.order.items"},
+		{Variable: "etag", Source: model.HeaderSource, Expression: "etag"},
+		{Variable: "status", Source: model.BodyRegexSource, Expression: `"status":\s*"(\w+)"`},
+		{Variable: "match", Source: model.BodyRegexSource, Expression: `[0-9]+`},
+		{Variable: "segment", Source: model.PathSegmentSource, Expression: "1"},
+		{Variable: "last", Source: model.PathSegmentSource, Expression: "-1"},
+	}
+	payload := `{"order": {"id": 42, "status": "open", "items": ["book"]}}`
+	headers := map[string][]string{"Etag": {`"v1"`}}
+
+	values, err := ExtractValues(extractions, nil, headers, "/orders/42/items", payload, logging.NewLogger("test"))
+	if err != nil {
+		t.Fatalf("no extraction error expected, but got %s", err)
+	}
+
+	expected := map[string]string{
+		"orderId": "42",
+		"items":   `["book"]`,
+		"etag":    `"v1"`,
+		"status":  "open",
+		"match":   "42",
+		"segment": "42",
+		"last":    "items",
+	}
+	for variable, value := range expected {
+		if values[variable] != value {
+			t.Errorf("expected <%s> = [%s] but received [%s]", variable, value, values[variable])
+		}
+	}
+}
+
+func TestExtractXPath(t *testing.T) {
+	extractions := []model.Extraction{
+		{Variable: "id", Source: model.XPathSource, Expression: "/o:order/@id"},
+	}
+	payload := `<order xmlns="urn:orders" id="7"/>`
+
+	values, err := ExtractValues(extractions, map[string]string{"o": "urn:orders"}, nil, "", payload, logging.NewLogger("test"))
+	if err != nil || values["id"] != "7" {
+		t.Errorf("expected <id> = [7] but received [%s] - %v", values["id"], err)
+	}
+}
+
+func TestExtractionErrors(t *testing.T) {
+	extractions := []model.Extraction{
+		{Variable: "id", Source: model.JsonPathSource, Expression: "$.id"},
+		{Variable: "missing", Source: model.JsonPathSource, Expression: "$.missing"},
+		{Variable: "etag", Source: model.HeaderSource, Expression: "ETag"},
+		{Variable: "segment", Source: model.PathSegmentSource, Expression: "0"},
+	}
+
+	values, err := ExtractValues(extractions, nil, nil, "", `{"id": "a"}`, logging.NewLogger("test"))
+	expected := "extraction error - variable <missing> - jsonpath <$.missing> - no value found\n" +
+		"extraction error - variable <etag> - header <ETag> - header missing\n" +
+		"extraction error - variable <segment> - path segment <0> - no path received"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected extraction errors [%s], but got [%v]", expected, err)
+	}
+	if values["id"] != "a" {
+		t.Errorf("expected the values found to be returned, but received %v", values)
+	}
+}
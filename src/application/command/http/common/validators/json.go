@@ -0,0 +1,90 @@
+package validators
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// applyJsonMatchers validates the values of the actual payload that are expected to match a matcher & replaces
+// these matchers with @ignore@, so that the comparator skips them. Matchers are only evaluated where the actual
+// payload has a value, missing values are reported by the comparator.
+func applyJsonMatchers(expected []byte, actual []byte) ([]byte, []error) {
+	if !bytes.Contains(expected, []byte("@")) {
+		return expected, nil
+	}
+
+	// invalid payloads are reported by the comparator
+	expectedDocument, err := decodeJson(expected)
+	if err != nil {
+		return expected, nil
+	}
+	actualDocument, err := decodeJson(actual)
+	if err != nil {
+		return expected, nil
+	}
+
+	var errs []error
+	replaced, changed := replaceJsonMatchers(expectedDocument, actualDocument, true, "$", &errs)
+	if !changed {
+		return expected, nil
+	}
+	result, err := json.Marshal(replaced)
+	if err != nil {
+		return expected, nil
+	}
+
+	return result, errs
+}
+
+// numbers are decoded as json.Number, so that they are encoded again without losing precision
+func decodeJson(payload []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var document any
+	err := decoder.Decode(&document)
+	return document, err
+}
+
+func replaceJsonMatchers(expected any, actual any, found bool, path string, errs *[]error) (any, bool) {
+	switch e := expected.(type) {
+	case string:
+		if !found || e == ignoreFlag || !isMatcher(e) {
+			return e, false
+		}
+		if matched, err := matchValue(e, jsonText(actual)); err != nil {
+			*errs = append(*errs, errors.New(fmt.Sprintf("[%s] - %s", path, err)))
+		} else if !matched {
+			*errs = append(*errs, errors.New(fmt.Sprintf("[%s] - value mismatch - expected [%s] but received [%s]",
+				path, e, jsonText(actual))))
+		}
+		return ignoreFlag, true
+	case map[string]any:
+		actualObject, _ := actual.(map[string]any)
+		changed := false
+		for key, value := range e {
+			actualValue, exists := actualObject[key]
+			var replaced bool
+			e[key], replaced = replaceJsonMatchers(value, actualValue, exists, path+"."+key, errs)
+			changed = changed || replaced
+		}
+		return e, changed
+	case []any:
+		actualArray, _ := actual.([]any)
+		changed := false
+		for i, value := range e {
+			var actualValue any
+			if i < len(actualArray) {
+				actualValue = actualArray[i]
+			}
+			var replaced bool
+			e[i], replaced = replaceJsonMatchers(value, actualValue, i < len(actualArray), fmt.Sprintf("%s[%d]", path, i), errs)
+			changed = changed || replaced
+		}
+		return e, changed
+	default:
+		return e, false
+	}
+}
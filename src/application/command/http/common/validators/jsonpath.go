@@ -0,0 +1,153 @@
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// validateJsonPath evaluates every assertion on the document, all failed assertions are reported
+func validateJsonPath(assertions []model.JsonPathAssertion, payload []byte) []error {
+	var document any
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return []error{errors.New(fmt.Sprintf("received payload is invalid json - %s", err))}
+	}
+
+	var errs []error
+	for _, assertion := range assertions {
+		if err := validateJsonPathAssertion(assertion, document); err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("jsonpath <%s> %s", assertion.Path, err)))
+		}
+	}
+
+	return errs
+}
+
+func validateJsonPathAssertion(assertion model.JsonPathAssertion, document any) error {
+	evaluable, err := jsonpath.New(assertion.Path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("- invalid expression - %s", err))
+	}
+	// evaluation errors are unknown keys, indexes out of bounds or keys of values that are not objects
+	value, err := evaluable(context.Background(), document)
+	found := err == nil
+
+	switch assertion.Matcher {
+	case model.Exists:
+		if !found {
+			return errors.New("- no value found")
+		}
+		return nil
+	case model.NotExists:
+		if found {
+			return errors.New(fmt.Sprintf("- expected no value but found [%s]", jsonText(value)))
+		}
+		return nil
+	}
+
+	if !found {
+		return errors.New("- no value found")
+	}
+
+	switch assertion.Matcher {
+	case model.Regex:
+		pattern, err := regexp.Compile("^(?:" + assertion.Expected + ")$")
+		if err != nil {
+			return errors.New(fmt.Sprintf("- invalid regex [%s] - %s", assertion.Expected, err))
+		}
+		if !pattern.MatchString(jsonText(value)) {
+			return mismatch(assertion, jsonText(value))
+		}
+	case model.Type:
+		if actualType := jsonType(value); actualType != assertion.Expected {
+			return mismatch(assertion, actualType)
+		}
+	case model.Size:
+		expectedSize, err := strconv.Atoi(assertion.Expected)
+		if err != nil {
+			return errors.New(fmt.Sprintf("- invalid size [%s]", assertion.Expected))
+		}
+		actualSize, ok := jsonSize(value)
+		if !ok {
+			return errors.New(fmt.Sprintf("- %s value [%s] has no size", jsonType(value), jsonText(value)))
+		}
+		if actualSize != expectedSize {
+			return mismatch(assertion, strconv.Itoa(actualSize))
+		}
+	default:
+		if isMatcher(assertion.Expected) {
+			if matched, err := matchValue(assertion.Expected, jsonText(value)); err != nil {
+				return errors.New(fmt.Sprintf("- %s", err))
+			} else if !matched {
+				return mismatch(assertion, jsonText(value))
+			}
+		} else if !jsonEquals(assertion.Expected, value) {
+			return mismatch(assertion, jsonText(value))
+		}
+	}
+
+	return nil
+}
+
+func mismatch(assertion model.JsonPathAssertion, actual string) error {
+	return errors.New(fmt.Sprintf("%s mismatch - expected [%s] but received [%s]", assertion.Matcher, assertion.Expected, actual))
+}
+
+// the expected value is parsed as JSON, strings match also without quotes
+func jsonEquals(expected string, value any) bool {
+	if text, ok := value.(string); ok && text == expected {
+		return true
+	}
+
+	var expectedValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(expectedValue, value)
+}
+
+func jsonText(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func jsonSize(value any) (int, bool) {
+	switch v := value.(type) {
+	case []any:
+		return len(v), true
+	case map[string]any:
+		return len(v), true
+	case string:
+		return len([]rune(v)), true
+	default:
+		return 0, false
+	}
+}
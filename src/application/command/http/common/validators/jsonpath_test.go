@@ -0,0 +1,92 @@
+package validators
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"strings"
+	"testing"
+)
+
+const orderJson = `{
+	"id": "4f9c1e2a-7b1d-4c4e-9a57-2f3b8d6a1c10",
+	"number": 42,
+	"paid": false,
+	"created": "2024-05-01T10:00:00Z",
+	"customer": {"name": "Jane", "vip": null},
+	"items": [
+		{"sku": "A-1", "price": 19.99},
+		{"sku": "B-2", "price": 5}
+	]
+}`
+
+func TestJsonPath(t *testing.T) {
+	assertions := []model.JsonPathAssertion{
+		{Path: "$.number", Matcher: model.Equals, Expected: "42"},
+		{Path: "$.customer.name", Matcher: model.Equals, Expected: "Jane"},
+		{Path: "$.customer.name", Matcher: model.Equals, Expected: `"Jane"`},
+		{Path: "$.paid", Matcher: model.Equals, Expected: "false"},
+		{Path: "$.items[1]", Matcher: model.Equals, Expected: `{"price": 5, "sku": "B-2"}`},
+		{Path: "$.id", Matcher: model.Regex, Expected: "[0-9a-f-]{36}"},
+		{Path: "$.created", Matcher: model.Exists},
+		{Path: "$.deleted", Matcher: model.NotExists},
+		{Path: "$.items[5]", Matcher: model.NotExists},
+		{Path: "$.customer.vip", Matcher: model.Type, Expected: "null"},
+		{Path: "$.items", Matcher: model.Type, Expected: "array"},
+		{Path: "$.items[0].price", Matcher: model.Type, Expected: "number"},
+		{Path: "$.items", Matcher: model.Size, Expected: "2"},
+		{Path: "$.items[*].sku", Matcher: model.Equals, Expected: `["A-1", "B-2"]`},
+		{Path: "$..price", Matcher: model.Size, Expected: "2"},
+	}
+
+	if errs := validateJsonPath(assertions, []byte(orderJson)); errs != nil {
+		t.Errorf("No jsonpath validation error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestJsonPathErrors(t *testing.T) {
+	assertions := []model.JsonPathAssertion{
+		{Path: "$.number", Matcher: model.Equals, Expected: "43"},
+		{Path: "$.id", Matcher: model.Regex, Expected: "[0-9]+"},
+		{Path: "$.customer.email", Matcher: model.Exists},
+		{Path: "$.paid", Matcher: model.NotExists},
+		{Path: "$.customer", Matcher: model.Type, Expected: "array"},
+		{Path: "$.items", Matcher: model.Size, Expected: "3"},
+		{Path: "$.number", Matcher: model.Size, Expected: "1"},
+		{Path: "$.items[", Matcher: model.Exists},
+	}
+
+	errs := validateJsonPath(assertions, []byte(orderJson))
+
+	expectedErrors := []string{
+		"jsonpath <$.number> equals mismatch - expected [43] but received [42]",
+		"jsonpath <$.id> regex mismatch - expected [[0-9]+] but received [4f9c1e2a-7b1d-4c4e-9a57-2f3b8d6a1c10]",
+		"jsonpath <$.customer.email> - no value found",
+		"jsonpath <$.paid> - expected no value but found [false]",
+		"jsonpath <$.customer> type mismatch - expected [array] but received [object]",
+		"jsonpath <$.items> size mismatch - expected [3] but received [2]",
+		"jsonpath <$.number> - number value [42] has no size",
+		"jsonpath <$.items[> - invalid expression",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, but got %s", len(expectedErrors), errors.Join(errs...))
+	}
+	for i, expected := range expectedErrors {
+		if !strings.HasPrefix(errs[i].Error(), expected) {
+			t.Errorf("Expected error [%s], but got [%s]", expected, errs[i])
+		}
+	}
+}
+
+func TestJsonPathWithFullComparison(t *testing.T) {
+	expected := &model.ExpectedPayload{
+		Payload:     `{"number": 42, "id": "@ignore@"}`,
+		PayloadType: model.Json,
+		JsonPath:    []model.JsonPathAssertion{{Path: "$.id", Matcher: model.Regex, Expected: "[a-z]+"}},
+	}
+
+	err := ValidateHttpBody(expected, body(`{"number": 41, "id": "abc"}`), logger)
+
+	if err == nil || !strings.Contains(err.Error(), "json validation errors") || strings.Contains(err.Error(), "jsonpath") {
+		t.Errorf("Only the full comparison error expected, but got %s", err)
+	}
+}
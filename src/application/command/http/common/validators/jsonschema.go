@@ -0,0 +1,97 @@
+package validators
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// inline schemas are located in the base directory, so that their relative references are resolved like the ones of schema files
+const inlineSchemaName = "inline-schema.json"
+
+var schemaMessages = message.NewPrinter(language.English)
+
+// validateJsonSchema validates the payload against a schema given inline or as a file, each violation is reported with
+// the JSON pointer of the invalid value. The draft is selected with $schema, draft 2020-12 is used if it is not set.
+func validateJsonSchema(schema string, schemaFile string, payload []byte) []error {
+	compiled, err := compileJsonSchema(schema, schemaFile)
+	if err != nil {
+		return []error{errors.New(fmt.Sprintf("invalid json schema - %s", err))}
+	}
+
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return []error{errors.New(fmt.Sprintf("received payload is invalid json - %s", err))}
+	}
+
+	var validationError *jsonschema.ValidationError
+	if err := compiled.Validate(document); errors.As(err, &validationError) {
+		return schemaViolations(validationError)
+	} else if err != nil {
+		return []error{err}
+	}
+
+	return nil
+}
+
+func compileJsonSchema(schema string, schemaFile string) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+
+	if clarumstrings.IsNotBlank(schemaFile) {
+		return compiler.Compile(config.ResolvePath(schemaFile))
+	}
+
+	document, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	location := filepath.Join(config.BaseDir(), inlineSchemaName)
+	if err := compiler.AddResource(location, document); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile(location)
+}
+
+// only the leaves of the error tree are violations, the other errors summarize them, like "allOf failed"
+func schemaViolations(validationError *jsonschema.ValidationError) []error {
+	var violations []string
+	var collect func(*jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, fmt.Sprintf("at '%s': %s",
+				jsonPointer(e.InstanceLocation), e.ErrorKind.LocalizedString(schemaMessages)))
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationError)
+
+	// sorted, the validation of object properties has no fixed order
+	slices.Sort(violations)
+	errs := make([]error, len(violations))
+	for i, violation := range violations {
+		errs[i] = errors.New(violation)
+	}
+
+	return errs
+}
+
+func jsonPointer(location []string) string {
+	var pointer strings.Builder
+	for _, token := range location {
+		pointer.WriteString("/")
+		pointer.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return pointer.String()
+}
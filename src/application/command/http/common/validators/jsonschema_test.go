@@ -0,0 +1,96 @@
+package validators
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["number", "items"],
+	"properties": {
+		"number": {"type": "integer", "minimum": 1},
+		"items": {
+			"type": "array",
+			"items": {"$ref": "#/$defs/item"}
+		}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"required": ["sku"],
+			"properties": {"sku": {"type": "string"}, "price": {"type": "number"}}
+		}
+	}
+}`
+
+func TestJsonSchema(t *testing.T) {
+	payload := `{"number": 42, "items": [{"sku": "A-1", "price": 19.99}, {"sku": "B-2"}]}`
+
+	if errs := validateJsonSchema(orderSchema, "", []byte(payload)); errs != nil {
+		t.Errorf("No json schema validation error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestJsonSchemaViolations(t *testing.T) {
+	payload := `{"number": 0, "items": [{"sku": 1}, {"price": "free"}]}`
+
+	errs := validateJsonSchema(orderSchema, "", []byte(payload))
+
+	expectedPointers := []string{"at '/items/0/sku'", "at '/items/1'", "at '/items/1/price'", "at '/number'"}
+	if len(errs) != len(expectedPointers) {
+		t.Fatalf("Expected %d violations, but got %s", len(expectedPointers), errors.Join(errs...))
+	}
+	for i, pointer := range expectedPointers {
+		if !strings.HasPrefix(errs[i].Error(), pointer) {
+			t.Errorf("Expected violation %s, but got %s", pointer, errs[i])
+		}
+	}
+}
+
+func TestJsonSchemaDraft07File(t *testing.T) {
+	dir := t.TempDir()
+	// the item schema is referenced relative to the schema file
+	item := `{"type": "object", "required": ["sku"]}`
+	schema := `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "array",
+		"items": {"$ref": "item.json"},
+		"additionalItems": false
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "item.json"), []byte(item), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "orders.json"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := validateJsonSchema("", filepath.Join(dir, "orders.json"), []byte(`[{"sku": "A-1"}, {"price": 1}]`))
+
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "at '/1': missing property 'sku'") {
+		t.Errorf("Missing property violation expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestInvalidJsonSchema(t *testing.T) {
+	errs := validateJsonSchema(`{"type": 12}`, "", []byte(`{}`))
+
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "invalid json schema") {
+		t.Errorf("Invalid schema error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestValidateHttpBodyWithJsonSchema(t *testing.T) {
+	expected := &model.ExpectedPayload{JsonSchema: `{"type": "object", "required": ["id"]}`}
+
+	err := ValidateHttpBody(expected, body(`{"name": "Jane"}`), logger)
+
+	if err == nil || err.Error() != "json schema validation errors: [at '': missing property 'id']" {
+		t.Errorf("Json schema validation error expected, but got %s", err)
+	}
+}
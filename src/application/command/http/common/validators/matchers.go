@@ -0,0 +1,128 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// a matcher reports whether the actual value matches, arg is the text between the parentheses
+type matcher func(arg string, actual string) (bool, error)
+
+// Matchers can be used instead of any expected value, for example @regex([0-9]+)@, @isNumber()@ or @ignore@.
+// A matcher always covers the whole expected value. Values that are not a known matcher are compared literally.
+var matchers = map[string]matcher{
+	"ignore": func(arg string, actual string) (bool, error) {
+		return true, nil
+	},
+	// regex matches the whole value
+	"regex": func(arg string, actual string) (bool, error) {
+		pattern, err := regexp.Compile("^(?:" + arg + ")$")
+		if err != nil {
+			return false, err
+		}
+		return pattern.MatchString(actual), nil
+	},
+	"startsWith": func(arg string, actual string) (bool, error) {
+		return strings.HasPrefix(actual, arg), nil
+	},
+	"endsWith": func(arg string, actual string) (bool, error) {
+		return strings.HasSuffix(actual, arg), nil
+	},
+	"contains": func(arg string, actual string) (bool, error) {
+		return strings.Contains(actual, arg), nil
+	},
+	"equalsIgnoreCase": func(arg string, actual string) (bool, error) {
+		return strings.EqualFold(actual, arg), nil
+	},
+	"notEmpty": func(arg string, actual string) (bool, error) {
+		return actual != "", nil
+	},
+	"isNumber": func(arg string, actual string) (bool, error) {
+		_, err := strconv.ParseFloat(actual, 64)
+		return err == nil, nil
+	},
+	"isUUID": func(arg string, actual string) (bool, error) {
+		return uuidPattern.MatchString(actual), nil
+	},
+	// isISODateTime accepts RFC 3339 date-times, with or without fractional seconds
+	"isISODateTime": func(arg string, actual string) (bool, error) {
+		_, err := time.Parse(time.RFC3339, actual)
+		return err == nil, nil
+	},
+	"greaterThan": compareNumber(func(actual float64, limit float64) bool {
+		return actual > limit
+	}),
+	"lessThan": compareNumber(func(actual float64, limit float64) bool {
+		return actual < limit
+	}),
+}
+
+// matchValue reports whether the actual value equals the expected one, or matches it if it is a matcher.
+// An error is returned for matchers with invalid arguments.
+func matchValue(expected string, actual string) (bool, error) {
+	m, arg, ok := parseMatcher(expected)
+	if !ok {
+		return expected == actual, nil
+	}
+
+	matched, err := m(arg, actual)
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("invalid matcher [%s] - %s", expected, err))
+	}
+	return matched, nil
+}
+
+// matchAny reports whether one of the actual values matches the expected one
+func matchAny(expected string, actualValues []string) (bool, error) {
+	for _, actual := range actualValues {
+		if matched, err := matchValue(expected, actual); err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+func isMatcher(expected string) bool {
+	_, _, ok := parseMatcher(expected)
+	return ok
+}
+
+// parseMatcher splits an expected value like @name(arg)@ or @name@ into the matcher & its argument
+func parseMatcher(expected string) (matcher, string, bool) {
+	if len(expected) < 3 || !strings.HasPrefix(expected, "@") || !strings.HasSuffix(expected, "@") {
+		return nil, "", false
+	}
+
+	inner := expected[1 : len(expected)-1]
+	name, arg := inner, ""
+	if open := strings.IndexByte(inner, '('); open >= 0 {
+		if !strings.HasSuffix(inner, ")") {
+			return nil, "", false
+		}
+		name, arg = inner[:open], inner[open+1:len(inner)-1]
+	}
+
+	m, exists := matchers[name]
+	return m, arg, exists
+}
+
+func compareNumber(compare func(actual float64, limit float64) bool) matcher {
+	return func(arg string, actual string) (bool, error) {
+		limit, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+		if err != nil {
+			return false, errors.New(fmt.Sprintf("[%s] is not a number", arg))
+		}
+		actualNumber, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			return false, nil
+		}
+		return compare(actualNumber, limit), nil
+	}
+}
@@ -0,0 +1,145 @@
+package validators
+
+import (
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMatchValue(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		matched  bool
+	}{
+		{"@ignore@", "anything", true},
+		{"@regex([a-z]+-[0-9]{2})@", "abc-42", true},
+		{"@regex([a-z]+)@", "abc-42", false},
+		{"@regex((a|b),c)@", "b,c", true},
+		{"@startsWith(ord-)@", "ord-1", true},
+		{"@endsWith(.pdf)@", "a.txt", false},
+		{"@contains(Bearer )@", "Bearer token", true},
+		{"@equalsIgnoreCase(OPEN)@", "open", true},
+		{"@notEmpty()@", "", false},
+		{"@isNumber()@", "-4.2e3", true},
+		{"@isNumber@", "4x", false},
+		{"@isUUID()@", "3f2c1a6e-8b7d-4c1e-9f0a-2b3c4d5e6f70", true},
+		{"@isUUID()@", "3f2c1a6e", false},
+		{"@isISODateTime()@", "2024-05-01T10:00:00.123+02:00", true},
+		{"@isISODateTime()@", "2024-05-01", false},
+		{"@greaterThan(10)@", "10.5", true},
+		{"@greaterThan(10)@", "abc", false},
+		{"@lessThan(0)@", "1", false},
+		// values that are not a known matcher are compared literally
+		{"@user@", "@user@", true},
+		{"@unknown(1)@", "1", false},
+		{"plain", "plain", true},
+	}
+
+	for _, test := range tests {
+		if matched, err := matchValue(test.expected, test.actual); err != nil || matched != test.matched {
+			t.Errorf("expected [%s] matching [%s] to be %t, but got %t - %v", test.expected, test.actual, test.matched, matched, err)
+		}
+	}
+}
+
+func TestInvalidMatchers(t *testing.T) {
+	if _, err := matchValue("@regex([a-z)@", "a"); err == nil ||
+		err.Error() != "invalid matcher [@regex([a-z)@] - error parsing regexp: missing closing ]: `[a-z)$`" {
+		t.Errorf("expected invalid regex error, but got %v", err)
+	}
+	if _, err := matchValue("@greaterThan(ten)@", "11"); err == nil ||
+		err.Error() != "invalid matcher [@greaterThan(ten)@] - [ten] is not a number" {
+		t.Errorf("expected invalid number error, but got %v", err)
+	}
+}
+
+func TestMatchersInValidators(t *testing.T) {
+	req := createRealRequest()
+	req.URL.Path = "/orders/42/items"
+	req.URL.RawQuery = url.Values{"page": {"1", "2"}}.Encode()
+
+	if _, err := ValidatePath([]string{"orders", "@isNumber()@", "items"}, req.URL, logger); err != nil {
+		t.Errorf("no path validation error expected, but got %s", err)
+	}
+	if err := ValidateHttpHeaders(map[string]string{"Authorization": "@startsWith(Bearer )@"}, req.Header, logger); err != nil {
+		t.Errorf("no header validation error expected, but got %s", err)
+	}
+	if err := ValidateHttpQueryParams(map[string][]string{"page": {"@greaterThan(1)@"}}, req.URL, logger); err != nil {
+		t.Errorf("no query param validation error expected, but got %s", err)
+	}
+	if err := validatePayload("@regex(order [0-9]+ created)@", []byte("order 42 created"), model.Plaintext, logger); err != nil {
+		t.Errorf("no payload validation error expected, but got %s", err)
+	}
+}
+
+func TestMatcherMismatchesInValidators(t *testing.T) {
+	req := createRealRequest()
+	req.URL.Path = "/orders/abc"
+
+	_, err := ValidatePath([]string{"orders", "@isNumber()@"}, req.URL, logger)
+	if err == nil || err.Error() != "validation error - path mismatch - expected [orders/@isNumber()@] but received [orders/abc]" {
+		t.Errorf("path mismatch expected, but got %v", err)
+	}
+	_, err = ValidatePath([]string{"orders", "@isNumber()@", "items"}, req.URL, logger)
+	if err == nil || err.Error() != "validation error - path mismatch - expected [orders/@isNumber()@/items] but received [orders/abc]" {
+		t.Errorf("path mismatch expected, but got %v", err)
+	}
+	err = ValidateHttpHeaders(map[string]string{"Connection": "@regex(close|upgrade)@"}, req.Header, logger)
+	if err == nil || err.Error() != "validation error - header <connection> mismatch - expected [@regex(close|upgrade)@] but received [[keep-alive]]" {
+		t.Errorf("header mismatch expected, but got %v", err)
+	}
+	err = validatePayload("@isUUID()@", []byte("42"), model.Plaintext, logger)
+	if err == nil || err.Error() != "validation error - payload mismatch - expected [@isUUID()@] but received [42]" {
+		t.Errorf("payload mismatch expected, but got %v", err)
+	}
+}
+
+func TestJsonMatchers(t *testing.T) {
+	expected := `{"id": "@isNumber()@", "created": "@isISODateTime()@", "items": [{"sku": "@startsWith(A-)@", "count": 1}], "total": 12345678901234567890}`
+	actual := `{"id": 42, "created": "2024-05-01T10:00:00Z", "items": [{"sku": "A-1", "count": 1}], "total": 12345678901234567890}`
+
+	if err := validatePayload(expected, []byte(actual), model.Json, logger); err != nil {
+		t.Errorf("no json validation error expected, but got %s", err)
+	}
+
+	actual = `{"id": "x", "created": "2024-05-01T10:00:00Z", "items": [{"sku": "B-1", "count": 2}], "total": 12345678901234567890}`
+	err := validatePayload(expected, []byte(actual), model.Json, logger)
+	if err == nil {
+		t.Fatalf("json validation errors expected")
+	}
+	for _, message := range []string{
+		"[$.id] - value mismatch - expected [@isNumber()@] but received [x]",
+		"[$.items[0].sku] - value mismatch - expected [@startsWith(A-)@] but received [B-1]",
+		"[$.items[0].count] - value mismatch - expected [1] but received [2]",
+	} {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("expected error [%s] in [%s]", message, err)
+		}
+	}
+}
+
+func TestXmlAndXPathMatchers(t *testing.T) {
+	expected := `<order id="@isNumber()@"><created>@isISODateTime()@</created></order>`
+
+	if errs := compareXml([]byte(expected), []byte(`<order id="42"><created>2024-05-01T10:00:00Z</created></order>`)); errs != nil {
+		t.Errorf("no xml validation error expected, but got %s", errs)
+	}
+	errs := compareXml([]byte(expected), []byte(`<order id="x"><created>2024-05-01T10:00:00Z</created></order>`))
+	if len(errs) != 1 || errs[0].Error() != "/order - attribute <id> mismatch - expected [@isNumber()@] but received [x]" {
+		t.Errorf("attribute mismatch expected, but got %s", errs)
+	}
+
+	payload := []byte(`<order id="42"/>`)
+	if errs := validateXPath(map[string]string{"/order/@id": "@greaterThan(40)@"}, nil, payload); errs != nil {
+		t.Errorf("no xpath validation error expected, but got %s", errs)
+	}
+}
+
+func TestJsonPathMatchers(t *testing.T) {
+	if errs := validateJsonPath([]model.JsonPathAssertion{{Path: "$.id", Expected: "@lessThan(40)@"}}, []byte(`{"id": 42}`)); len(errs) != 1 ||
+		errs[0].Error() != "jsonpath <$.id> equals mismatch - expected [@lessThan(40)@] but received [42]" {
+		t.Errorf("jsonpath mismatch expected, but got %s", errs)
+	}
+}
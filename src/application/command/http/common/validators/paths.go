@@ -0,0 +1,122 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"regexp"
+	"strings"
+)
+
+const (
+	anySegment  = "*"
+	anySegments = "**"
+)
+
+var pathVariablePattern = regexp.MustCompile(`^\{([^{}/]+)}$`)
+
+// PathTemplate is an expected path whose segments may be path variables like {id}, which capture one segment,
+// the wildcards * for one segment & ** for any number of segments, or matchers like @isNumber()@.
+// For example /orders/{id}/items/* or /files/**.
+type PathTemplate struct {
+	segments []string
+	template bool
+}
+
+// NewPathTemplate joins the path elements into a template. Matchers are tried on an empty value,
+// so that invalid arguments are reported here & not for every matched path.
+func NewPathTemplate(pathElements ...string) (*PathTemplate, error) {
+	result := &PathTemplate{}
+
+	for _, element := range pathElements {
+		// matchers may contain a "/", so they are never split
+		segments := []string{element}
+		if !isMatcher(element) {
+			segments = splitPath(element)
+		}
+
+		for _, segment := range segments {
+			if isMatcher(segment) {
+				if _, err := matchValue(segment, ""); err != nil {
+					return nil, errors.New(fmt.Sprintf("path - %s", err))
+				}
+				result.template = true
+			} else if segment == anySegment || segment == anySegments || pathVariablePattern.MatchString(segment) {
+				result.template = true
+			}
+			result.segments = append(result.segments, segment)
+		}
+	}
+
+	return result, nil
+}
+
+// IsTemplate is false if the path is compared literally
+func (t *PathTemplate) IsTemplate() bool {
+	return t.template
+}
+
+// Match returns the segments captured by the path variables, if the path matches the template
+func (t *PathTemplate) Match(actualPath string) (map[string]string, bool) {
+	pathVariables := make(map[string]string)
+	if !matchSegments(t.segments, splitPath(actualPath), pathVariables) {
+		return nil, false
+	}
+
+	return pathVariables, true
+}
+
+func (t *PathTemplate) String() string {
+	return strings.Join(t.segments, "/")
+}
+
+// matchSegments backtracks over the ways ** can match, the variables of failed attempts are removed again
+func matchSegments(expected []string, actual []string, pathVariables map[string]string) bool {
+	if len(expected) == 0 {
+		return len(actual) == 0
+	}
+
+	segment := expected[0]
+	if segment == anySegments {
+		for i := 0; i <= len(actual); i++ {
+			if matchSegments(expected[1:], actual[i:], pathVariables) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(actual) == 0 {
+		return false
+	}
+
+	if variable := pathVariablePattern.FindStringSubmatch(segment); variable != nil {
+		previous, existed := pathVariables[variable[1]]
+		pathVariables[variable[1]] = actual[0]
+		if matchSegments(expected[1:], actual[1:], pathVariables) {
+			return true
+		}
+		if existed {
+			pathVariables[variable[1]] = previous
+		} else {
+			delete(pathVariables, variable[1])
+		}
+		return false
+	}
+
+	if segment != anySegment {
+		// matchers were validated when the template was created
+		if matched, _ := matchValue(segment, actual[0]); !matched {
+			return false
+		}
+	}
+	return matchSegments(expected[1:], actual[1:], pathVariables)
+}
+
+func splitPath(path string) []string {
+	cleanedPath := utils.CleanPath(path)
+	if cleanedPath == "." || cleanedPath == "" {
+		return nil
+	}
+
+	return strings.Split(cleanedPath, "/")
+}
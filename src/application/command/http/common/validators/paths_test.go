@@ -0,0 +1,61 @@
+package validators
+
+import (
+	"maps"
+	"net/url"
+	"testing"
+)
+
+func TestPathTemplates(t *testing.T) {
+	tests := []struct {
+		template  []string
+		path      string
+		matched   bool
+		variables map[string]string
+	}{
+		{[]string{"/orders/{id}/items/*"}, "/orders/42/items/7", true, map[string]string{"id": "42"}},
+		{[]string{"orders", "{id}", "items", "*"}, "/orders/42/items", false, nil},
+		{[]string{"/orders/{id}/items/{itemId}"}, "/orders/42/items/7/", true, map[string]string{"id": "42", "itemId": "7"}},
+		{[]string{"/files/**"}, "/files", true, map[string]string{}},
+		{[]string{"/files/**"}, "/files/a/b/c.txt", true, map[string]string{}},
+		{[]string{"/**/{name}"}, "/files/a/b/c.txt", true, map[string]string{"name": "c.txt"}},
+		{[]string{"/**/{id}/items/**"}, "/a/b/42/items/1/2", true, map[string]string{"id": "42"}},
+		{[]string{"/orders/{id}", "@isNumber()@"}, "/orders/abc/42", true, map[string]string{"id": "abc"}},
+		{[]string{"/orders/{id}", "@isNumber()@"}, "/orders/42/abc", false, nil},
+		{[]string{"/orders/@regex([0-9]+/[0-9]+)@"}, "/orders/4/2", false, nil},
+		{[]string{"/orders", "@regex(.*)@"}, "/orders/x", true, map[string]string{}},
+	}
+
+	for _, test := range tests {
+		template, err := NewPathTemplate(test.template...)
+		if err != nil {
+			t.Fatalf("no template error expected for %v, but got %s", test.template, err)
+		}
+		variables, matched := template.Match(test.path)
+		if matched != test.matched || !maps.Equal(variables, test.variables) {
+			t.Errorf("expected %v matching [%s] to be %t with %v, but got %t with %v",
+				test.template, test.path, test.matched, test.variables, matched, variables)
+		}
+	}
+}
+
+func TestInvalidPathTemplate(t *testing.T) {
+	if _, err := NewPathTemplate("orders", "@regex([0-9)@"); err == nil ||
+		err.Error() != "path - invalid matcher [@regex([0-9)@] - error parsing regexp: missing closing ]: `[0-9)$`" {
+		t.Errorf("expected invalid matcher error, but got %v", err)
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	actualUrl, _ := url.Parse("/orders/42/items/7")
+
+	variables, err := ValidatePath([]string{"orders", "{id}", "items", "*"}, actualUrl, logger)
+	if err != nil || variables["id"] != "42" {
+		t.Errorf("expected the path variable <id> = [42], but got %v - %v", variables, err)
+	}
+
+	_, err = ValidatePath([]string{"/orders/{id}"}, actualUrl, logger)
+	if err == nil || err.Error() != "validation error - path mismatch - expected [orders/{id}] but received [orders/42/items/7]" {
+		t.Errorf("path mismatch expected, but got %v", err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/go-clarum/agent/application/command/http/common/model"
 	"github.com/go-clarum/agent/application/command/http/common/utils"
-	"github.com/go-clarum/agent/application/utils/arrays"
 	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
 	"github.com/go-clarum/agent/infrastructure/logging"
 	"github.com/go-clarum/clarum-json/comparator"
@@ -13,22 +12,35 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"path"
 	"strings"
 )
 
-func ValidatePath(expectedPath []string, actualUrl *url.URL, logger *logging.Logger) error {
-	cleanedExpected := cleanPath(utils.BuildPath("", expectedPath...))
-	cleanedActual := cleanPath(actualUrl.Path)
+// ValidatePath compares the cleaned paths. The expected path may be a template, see PathTemplate.
+// The segments captured by the path variables of the template are returned.
+func ValidatePath(expectedPath []string, actualUrl *url.URL, logger *logging.Logger) (map[string]string, error) {
+	cleanedActual := utils.CleanPath(actualUrl.Path)
 
-	if cleanedExpected != cleanedActual {
-		return handleError(logger, "validation error - path mismatch - expected [%s] but received [%s]",
-			cleanedExpected, cleanedActual)
+	template, err := NewPathTemplate(expectedPath...)
+	if err != nil {
+		return nil, handleError(logger, "validation error - %s", err)
+	}
+
+	var pathVariables map[string]string
+	if !template.IsTemplate() {
+		cleanedExpected := utils.CleanPath(utils.BuildPath("", expectedPath...))
+		if cleanedExpected != cleanedActual {
+			return nil, handleError(logger, "validation error - path mismatch - expected [%s] but received [%s]",
+				cleanedExpected, cleanedActual)
+		}
+	} else if variables, matched := template.Match(actualUrl.Path); !matched {
+		return nil, handleError(logger, "validation error - path mismatch - expected [%s] but received [%s]",
+			template, cleanedActual)
 	} else {
-		logger.Info("path validation successful")
+		pathVariables = variables
 	}
+	logger.Info("path validation successful")
 
-	return nil
+	return pathVariables, nil
 }
 
 func ValidateHttpMethod(expectedMethod string, actualMethod string, logger *logging.Logger) error {
@@ -42,6 +54,36 @@ func ValidateHttpMethod(expectedMethod string, actualMethod string, logger *logg
 	return nil
 }
 
+// ValidateProtocol compares the negotiated protocol version, for example "HTTP/2.0", if one is expected.
+// The expected protocol may be shortened to the version, like "2" or "HTTP/2".
+func ValidateProtocol(expectedProtocol string, actualProtocol string, logger *logging.Logger) error {
+	if clarumstrings.IsBlank(expectedProtocol) {
+		return nil
+	}
+
+	normalizedExpected := normalizeProtocol(expectedProtocol)
+	if normalizedExpected != actualProtocol {
+		return handleError(logger, "validation error - protocol mismatch - expected [%s] but received [%s]",
+			normalizedExpected, actualProtocol)
+	} else {
+		logger.Info("protocol validation successful")
+	}
+
+	return nil
+}
+
+func normalizeProtocol(protocol string) string {
+	normalized := strings.ToUpper(strings.TrimSpace(protocol))
+	if !strings.HasPrefix(normalized, "HTTP/") {
+		normalized = "HTTP/" + normalized
+	}
+	if !strings.Contains(normalized, ".") {
+		normalized = normalized + ".0"
+	}
+
+	return normalized
+}
+
 func ValidateHttpHeaders(expectedHeaders map[string]string, actualHeaders http.Header, logger *logging.Logger) error {
 	if err := validateHeaders(expectedHeaders, actualHeaders); err != nil {
 		return handleError(logger, "%s", err)
@@ -62,7 +104,9 @@ func validateHeaders(expectedHeaders map[string]string, actualHeaders http.Heade
 	for header, expectedValue := range expectedHeaders {
 		lowerCaseExpectedHeader := strings.ToLower(header)
 		if receivedValues, exists := lowerCaseReceivedHeaders[lowerCaseExpectedHeader]; exists {
-			if !arrays.Contains(receivedValues, expectedValue) {
+			if matched, err := matchAny(expectedValue, receivedValues); err != nil {
+				return errors.New(fmt.Sprintf("validation error - header <%s> - %s", lowerCaseExpectedHeader, err))
+			} else if !matched {
 				return errors.New(fmt.Sprintf("validation error - header <%s> mismatch - expected [%s] but received [%s]",
 					lowerCaseExpectedHeader, expectedValue, receivedValues))
 			}
@@ -92,7 +136,9 @@ func validateQueryParams(expectedQueryParams map[string][]string, params url.Val
 	for param, expectedValues := range expectedQueryParams {
 		if receivedValues, exists := params[param]; exists {
 			for _, expectedValue := range expectedValues {
-				if !arrays.Contains(receivedValues, expectedValue) {
+				if matched, err := matchAny(expectedValue, receivedValues); err != nil {
+					return errors.New(fmt.Sprintf("validation error - query param <%s> - %s", param, err))
+				} else if !matched {
 					return errors.New(fmt.Sprintf("validation error - query param <%s> values mismatch - expected [%v] but received [%s]",
 						param, expectedValues, receivedValues))
 				}
@@ -116,6 +162,62 @@ func ValidateHttpStatusCode(expectedStatusCode int, actualStatusCode int, logger
 	return nil
 }
 
+// ValidateHttpBody validates the received payload against the binary payload if one is expected,
+// otherwise against the textual payload, the XPath and JSONPath assertions & the JSON schema. Every failed validation is reported.
+func ValidateHttpBody(expected *model.ExpectedPayload, actualPayload io.ReadCloser, logger *logging.Logger) error {
+	hasSchema := clarumstrings.IsNotBlank(expected.JsonSchema) || clarumstrings.IsNotBlank(expected.JsonSchemaFile)
+	hasAssertions := len(expected.XPath) > 0 || len(expected.JsonPath) > 0 || hasSchema
+
+	if expected.Binary != nil {
+		if clarumstrings.IsNotBlank(expected.Payload) || hasAssertions {
+			closeBody(logger, actualPayload)
+			return handleError(logger, "validation error - a binary payload cannot be validated with a payload, XPath, JSONPath or JSON schema")
+		}
+		return ValidateHttpBinaryPayload(expected.Binary, actualPayload, logger)
+	}
+	if !hasAssertions {
+		return ValidateHttpPayload(&expected.Payload, actualPayload, expected.PayloadType, logger)
+	}
+
+	defer closeBody(logger, actualPayload)
+	bodyBytes, err := io.ReadAll(actualPayload)
+	if err != nil {
+		return handleError(logger, "could not read body - %s", err)
+	}
+
+	var errs []error
+	if clarumstrings.IsNotBlank(expected.Payload) {
+		if err := validatePayload(expected.Payload, bodyBytes, expected.PayloadType, logger); err != nil {
+			errs = append(errs, handleError(logger, "%s", err))
+		} else {
+			logger.Info("payload validation successful")
+		}
+	}
+	if len(expected.XPath) > 0 {
+		if xpathErrs := validateXPath(expected.XPath, expected.Namespaces, bodyBytes); xpathErrs != nil {
+			errs = append(errs, handleError(logger, "xpath validation errors: [%s]", errors.Join(xpathErrs...)))
+		} else {
+			logger.Info("xpath validation successful")
+		}
+	}
+	if len(expected.JsonPath) > 0 {
+		if jsonPathErrs := validateJsonPath(expected.JsonPath, bodyBytes); jsonPathErrs != nil {
+			errs = append(errs, handleError(logger, "jsonpath validation errors: [%s]", errors.Join(jsonPathErrs...)))
+		} else {
+			logger.Info("jsonpath validation successful")
+		}
+	}
+	if hasSchema {
+		if schemaErrs := validateJsonSchema(expected.JsonSchema, expected.JsonSchemaFile, bodyBytes); schemaErrs != nil {
+			errs = append(errs, handleError(logger, "json schema validation errors: [%s]", errors.Join(schemaErrs...)))
+		} else {
+			logger.Info("json schema validation successful")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func ValidateHttpPayload(expectedPayload *string, actualPayload io.ReadCloser,
 	payloadType model.PayloadType, logger *logging.Logger) error {
 	defer closeBody(logger, actualPayload)
@@ -152,7 +254,9 @@ func validatePayload(expected string, actual []byte, payloadType model.PayloadTy
 	} else if payloadType == model.Plaintext {
 		receivedPayload := string(actual)
 
-		if expected != receivedPayload {
+		if matched, err := matchValue(expected, receivedPayload); err != nil {
+			return errors.New(fmt.Sprintf("validation error - payload - %s", err))
+		} else if !matched {
 			return errors.New(fmt.Sprintf("validation error - payload mismatch - expected [%s] but received [%s]",
 				expected, receivedPayload))
 		}
@@ -161,13 +265,18 @@ func validatePayload(expected string, actual []byte, payloadType model.PayloadTy
 			Recorder(recorder.NewDefaultRecorder()).
 			Build()
 
-		reporterLog, errs := jsonComparator.Compare([]byte(expected), actual)
+		expectedJson, matcherErrs := applyJsonMatchers([]byte(expected), actual)
+		reporterLog, err := jsonComparator.Compare(expectedJson, actual)
 
-		if errs != nil {
+		if errs := errors.Join(append(matcherErrs, err)...); errs != nil {
 			logger.Infof("json validation log: %s", reporterLog)
 			return errors.New(fmt.Sprintf("json validation errors: [%s]", errs))
 		}
 		logger.Debugf("json payload validation log: %s", reporterLog)
+	} else if payloadType == model.Xml {
+		if errs := compareXml([]byte(expected), actual); errs != nil {
+			return errors.New(fmt.Sprintf("xml validation errors: [%s]", errors.Join(errs...)))
+		}
 	}
 
 	return nil
@@ -178,8 +287,3 @@ func handleError(logger *logging.Logger, format string, a ...any) error {
 	logger.Errorf(errorMessage)
 	return errors.New(errorMessage)
 }
-
-// path.Clean() does not remove leading "/", so we do that ourselves
-func cleanPath(pathToClean string) string {
-	return strings.TrimPrefix(path.Clean(pathToClean), "/")
-}
@@ -1,7 +1,7 @@
 package validators
 
 import (
-	"github.com/go-clarum/agent/application/services/http/common/constants"
+	"github.com/go-clarum/agent/application/command/http/common/constants"
 	_ "github.com/go-clarum/agent/infrastructure/config"
 	"github.com/go-clarum/agent/infrastructure/logging"
 	"net/http"
@@ -14,7 +14,7 @@ func TestValidatePathOK(t *testing.T) {
 	req := createRealRequest()
 	path := []string{"myPath", "some/api"}
 
-	if err := ValidatePath(path, req.URL, logger); err != nil {
+	if _, err := ValidatePath(path, req.URL, logger); err != nil {
 		t.Errorf("No header validation error expected, but got %s", err)
 	}
 }
@@ -23,7 +23,7 @@ func TestValidatePathError(t *testing.T) {
 	req := createRealRequest()
 	path := []string{"blup/"}
 
-	err := ValidatePath(path, req.URL, logger)
+	_, err := ValidatePath(path, req.URL, logger)
 
 	if err == nil {
 		t.Errorf("Path validation error expected, but got none")
@@ -56,6 +56,26 @@ func TestValidateMethodError(t *testing.T) {
 	}
 }
 
+func TestValidateProtocolOK(t *testing.T) {
+	for _, expected := range []string{"", "2", "http/2", "HTTP/2.0"} {
+		if err := ValidateProtocol(expected, "HTTP/2.0", logger); err != nil {
+			t.Errorf("No protocol validation error expected for [%s], but got %s", expected, err)
+		}
+	}
+}
+
+func TestValidateProtocolError(t *testing.T) {
+	err := ValidateProtocol("2", "HTTP/1.1", logger)
+
+	if err == nil {
+		t.Fatalf("Protocol validation error expected, but got none")
+	}
+
+	if err.Error() != "validation error - protocol mismatch - expected [HTTP/2.0] but received [HTTP/1.1]" {
+		t.Errorf("Protocol validation error message is unexpected")
+	}
+}
+
 func TestValidateHeadersOK(t *testing.T) {
 	expectedHeaders := make(map[string]string)
 	expectedHeaders["Connection"] = "keep-alive"
@@ -92,7 +112,7 @@ func TestValidateMissingHeaderError(t *testing.T) {
 	expectedHeaders := make(map[string]string)
 	expectedHeaders["Connection"] = "keep-alive"
 	expectedHeaders[constants.ContentTypeHeaderName] = "application/json"
-	expectedHeaders[constants.AuthorizationHeaderName] = "something else"
+	expectedHeaders[constants.AuthorizationHeaderName] = "Bearer 0b79bab50daca910b000d4f1a2b675d604257e42"
 	expectedHeaders["traceid"] = "124245132"
 
 	req := createRealRequest()
@@ -0,0 +1,221 @@
+package validators
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/antchfx/xpath"
+	"io"
+	"strings"
+)
+
+const ignoreFlag = "@ignore@"
+
+// xmlNode is a node of a parsed XML document. Names are namespace-aware: the namespace URI is kept,
+// the prefix is not, so documents using different prefixes for the same namespace are equal.
+// Comments, processing instructions & whitespace-only text are not part of the document.
+type xmlNode struct {
+	kind       xpath.NodeType
+	name       xml.Name
+	attributes []xml.Attr
+	text       string
+	parent     *xmlNode
+	children   []*xmlNode
+	// index of the node among the children of its parent
+	index int
+}
+
+func parseXml(payload []byte) (*xmlNode, error) {
+	root := &xmlNode{kind: xpath.RootNode}
+	current := root
+	decoder := xml.NewDecoder(bytes.NewReader(payload))
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid xml - %s", err))
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlNode{kind: xpath.ElementNode, name: t.Name, attributes: withoutNamespaceDeclarations(t.Attr)}
+			current.append(element)
+			current = element
+		case xml.EndElement:
+			current = current.parent
+		case xml.CharData:
+			current.appendText(string(t))
+		}
+	}
+
+	if len(root.elements()) != 1 {
+		return nil, errors.New("invalid xml - expected exactly one root element")
+	}
+
+	return root, nil
+}
+
+func (node *xmlNode) append(child *xmlNode) {
+	child.parent = node
+	child.index = len(node.children)
+	node.children = append(node.children, child)
+}
+
+// adjacent character data, like text & CDATA sections, is merged into one text node
+func (node *xmlNode) appendText(text string) {
+	if len(node.children) > 0 {
+		if last := node.children[len(node.children)-1]; last.kind == xpath.TextNode {
+			last.text += text
+			return
+		}
+	}
+	if strings.TrimSpace(text) != "" {
+		node.append(&xmlNode{kind: xpath.TextNode, text: text})
+	}
+}
+
+func (node *xmlNode) elements() []*xmlNode {
+	var elements []*xmlNode
+	for _, child := range node.children {
+		if child.kind == xpath.ElementNode {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+// value is the text of the node and all its descendants
+func (node *xmlNode) value() string {
+	if node.kind == xpath.TextNode {
+		return node.text
+	}
+
+	var value strings.Builder
+	for _, child := range node.children {
+		value.WriteString(child.value())
+	}
+	return value.String()
+}
+
+// ownText is the text directly inside an element, without the text of its child elements
+func (node *xmlNode) ownText() string {
+	var text strings.Builder
+	for _, child := range node.children {
+		if child.kind == xpath.TextNode {
+			text.WriteString(child.text)
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
+
+func withoutNamespaceDeclarations(attributes []xml.Attr) []xml.Attr {
+	var result []xml.Attr
+	for _, attribute := range attributes {
+		if attribute.Name.Space == "xmlns" || (attribute.Name.Space == "" && attribute.Name.Local == "xmlns") {
+			continue
+		}
+		result = append(result, attribute)
+	}
+	return result
+}
+
+// compareXml compares two documents structurally: element & attribute names with their namespaces, attribute values
+// in any order, the order of child elements & their text without surrounding whitespace.
+// An element with the text @ignore@ is not validated further, an attribute with the value @ignore@ only has to exist.
+func compareXml(expected []byte, actual []byte) []error {
+	expectedDocument, err := parseXml(expected)
+	if err != nil {
+		return []error{errors.New(fmt.Sprintf("expected payload is %s", err))}
+	}
+	actualDocument, err := parseXml(actual)
+	if err != nil {
+		return []error{errors.New(fmt.Sprintf("received payload is %s", err))}
+	}
+
+	expectedRoot := expectedDocument.elements()[0]
+	return compareElements(expectedRoot, actualDocument.elements()[0], "/"+expectedRoot.name.Local)
+}
+
+// the path of an element is used in errors, it has the same form as an XPath expression without namespaces
+func compareElements(expected *xmlNode, actual *xmlNode, path string) []error {
+	if expected.name != actual.name {
+		return []error{errors.New(fmt.Sprintf("%s - element mismatch - expected [%s] but received [%s]",
+			path, qualifiedName(expected.name), qualifiedName(actual.name)))}
+	}
+	if expected.ownText() == ignoreFlag {
+		return nil
+	}
+
+	errs := compareAttributes(expected.attributes, actual.attributes, path)
+	expectedText, actualText := expected.ownText(), actual.ownText()
+	if matched, err := matchValue(expectedText, actualText); err != nil {
+		errs = append(errs, errors.New(fmt.Sprintf("%s - text - %s", path, err)))
+	} else if !matched {
+		errs = append(errs, errors.New(fmt.Sprintf("%s - text mismatch - expected [%s] but received [%s]",
+			path, expectedText, actualText)))
+	}
+
+	expectedChildren, actualChildren := expected.elements(), actual.elements()
+	if len(expectedChildren) != len(actualChildren) {
+		return append(errs, errors.New(fmt.Sprintf("%s - number of child elements mismatch - expected [%d] but received [%d]",
+			path, len(expectedChildren), len(actualChildren))))
+	}
+
+	totals := make(map[xml.Name]int)
+	for _, expectedChild := range expectedChildren {
+		totals[expectedChild.name]++
+	}
+	positions := make(map[xml.Name]int)
+	for i, expectedChild := range expectedChildren {
+		positions[expectedChild.name]++
+		childPath := path + "/" + expectedChild.name.Local
+		if totals[expectedChild.name] > 1 {
+			childPath = fmt.Sprintf("%s[%d]", childPath, positions[expectedChild.name])
+		}
+		errs = append(errs, compareElements(expectedChild, actualChildren[i], childPath)...)
+	}
+
+	return errs
+}
+
+func compareAttributes(expected []xml.Attr, actual []xml.Attr, path string) []error {
+	var errs []error
+
+	actualValues := make(map[xml.Name]string)
+	for _, attribute := range actual {
+		actualValues[attribute.Name] = attribute.Value
+	}
+	expectedNames := make(map[xml.Name]bool)
+
+	for _, attribute := range expected {
+		expectedNames[attribute.Name] = true
+		actualValue, exists := actualValues[attribute.Name]
+
+		if !exists {
+			errs = append(errs, errors.New(fmt.Sprintf("%s - attribute <%s> missing", path, qualifiedName(attribute.Name))))
+		} else if matched, err := matchValue(attribute.Value, actualValue); err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("%s - attribute <%s> - %s", path, qualifiedName(attribute.Name), err)))
+		} else if !matched {
+			errs = append(errs, errors.New(fmt.Sprintf("%s - attribute <%s> mismatch - expected [%s] but received [%s]",
+				path, qualifiedName(attribute.Name), attribute.Value, actualValue)))
+		}
+	}
+	for _, attribute := range actual {
+		if !expectedNames[attribute.Name] {
+			errs = append(errs, errors.New(fmt.Sprintf("%s - unexpected attribute <%s>", path, qualifiedName(attribute.Name))))
+		}
+	}
+
+	return errs
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return fmt.Sprintf("{%s}%s", name.Space, name.Local)
+}
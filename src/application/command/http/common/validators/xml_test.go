@@ -0,0 +1,107 @@
+package validators
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"io"
+	"strings"
+	"testing"
+)
+
+const soapEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ord="http://example.com/orders">
+	<soap:Body>
+		<ord:order id="42" status="open">
+			<ord:item sku="A-1">Keyboard</ord:item>
+			<ord:item sku="B-2"><![CDATA[Mouse & pad]]></ord:item>
+			<ord:created>2024-05-01T10:00:00Z</ord:created>
+		</ord:order>
+	</soap:Body>
+</soap:Envelope>`
+
+func TestXmlStructuralComparison(t *testing.T) {
+	// other prefixes, attribute order & whitespace
+	expected := `<env:Envelope xmlns:env="http://schemas.xmlsoap.org/soap/envelope/"><env:Body>
+		<order xmlns="http://example.com/orders" status="open" id="42">
+			<item sku="A-1">  Keyboard  </item>
+			<item sku="B-2">Mouse &amp; pad</item>
+			<created>@ignore@</created>
+		</order>
+	</env:Body></env:Envelope>`
+
+	if errs := compareXml([]byte(expected), []byte(soapEnvelope)); errs != nil {
+		t.Errorf("No xml validation error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestXmlIgnoreAttribute(t *testing.T) {
+	expected := `<order id="@ignore@"><item>Keyboard</item></order>`
+
+	if errs := compareXml([]byte(expected), []byte(`<order id="7"><item>Keyboard</item></order>`)); errs != nil {
+		t.Errorf("No xml validation error expected, but got %s", errors.Join(errs...))
+	}
+	if errs := compareXml([]byte(expected), []byte(`<order><item>Keyboard</item></order>`)); len(errs) != 1 ||
+		errs[0].Error() != "/order - attribute <id> missing" {
+		t.Errorf("Missing attribute error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestXmlMismatches(t *testing.T) {
+	expected := `<order xmlns="urn:a" id="1"><item>Keyboard</item><item>Mouse</item><note/></order>`
+	actual := `<order xmlns="urn:a" id="2" extra="x"><item>Keyboard</item><item>Pad</item><comment/></order>`
+
+	errs := compareXml([]byte(expected), []byte(actual))
+
+	expectedErrors := []string{
+		"/order - attribute <id> mismatch - expected [1] but received [2]",
+		"/order - unexpected attribute <extra>",
+		"/order/item[2] - text mismatch - expected [Mouse] but received [Pad]",
+		"/order/note - element mismatch - expected [{urn:a}note] but received [{urn:a}comment]",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, but got %s", len(expectedErrors), errors.Join(errs...))
+	}
+	for i, err := range errs {
+		if err.Error() != expectedErrors[i] {
+			t.Errorf("Expected error [%s], but got [%s]", expectedErrors[i], err)
+		}
+	}
+}
+
+func TestXmlNamespaceMismatch(t *testing.T) {
+	errs := compareXml([]byte(`<order xmlns="urn:a"/>`), []byte(`<order xmlns="urn:b"/>`))
+
+	if len(errs) != 1 || errs[0].Error() != "/order - element mismatch - expected [{urn:a}order] but received [{urn:b}order]" {
+		t.Errorf("Namespace mismatch error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestXmlChildCountMismatch(t *testing.T) {
+	errs := compareXml([]byte(`<order><item/></order>`), []byte(`<order><item/><item/></order>`))
+
+	if len(errs) != 1 || errs[0].Error() != "/order - number of child elements mismatch - expected [1] but received [2]" {
+		t.Errorf("Child count error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestInvalidXml(t *testing.T) {
+	errs := compareXml([]byte(`<order/>`), []byte(`<order>`))
+
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "received payload is invalid xml") {
+		t.Errorf("Invalid xml error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestValidateXmlPayload(t *testing.T) {
+	expected := `<order id="42"><item>Keyboard</item></order>`
+
+	err := ValidateHttpPayload(&expected, body(`<order id="42"><item>Mouse</item></order>`), model.Xml, logger)
+
+	if err == nil || err.Error() != "xml validation errors: [/order/item - text mismatch - expected [Keyboard] but received [Mouse]]" {
+		t.Errorf("Xml validation error expected, but got %s", err)
+	}
+}
+
+func body(payload string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(payload))
+}
@@ -0,0 +1,180 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"github.com/antchfx/xpath"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// validateXPath evaluates every expression on the document & compares the string value of the result with the expected one.
+// Prefixes in expressions must be bound to a namespace, names without prefix match elements of any namespace.
+// The expected value @ignore@ only checks that the expression selects a node.
+func validateXPath(expressions map[string]string, namespaces map[string]string, payload []byte) []error {
+	document, err := parseXml(payload)
+	if err != nil {
+		return []error{errors.New(fmt.Sprintf("received payload is %s", err))}
+	}
+
+	var errs []error
+	// sorted, so that the errors are always reported in the same order
+	for _, expression := range slices.Sorted(maps.Keys(expressions)) {
+		expected := expressions[expression]
+
+		actual, found, err := evaluateXPath(expression, namespaces, document)
+		if err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("xpath <%s> - %s", expression, err)))
+		} else if !found {
+			errs = append(errs, errors.New(fmt.Sprintf("xpath <%s> - no node found", expression)))
+		} else if matched, err := matchValue(expected, actual); err != nil {
+			errs = append(errs, errors.New(fmt.Sprintf("xpath <%s> - %s", expression, err)))
+		} else if !matched {
+			errs = append(errs, errors.New(fmt.Sprintf("xpath <%s> mismatch - expected [%s] but received [%s]",
+				expression, expected, actual)))
+		}
+	}
+
+	return errs
+}
+
+// evaluateXPath returns the string value of the result, for node sets the value of the first node
+func evaluateXPath(expression string, namespaces map[string]string, document *xmlNode) (string, bool, error) {
+	compiled, err := xpath.CompileWithNS(expression, namespaces)
+	if err != nil {
+		return "", false, errors.New(fmt.Sprintf("invalid expression - %s", err))
+	}
+
+	switch result := compiled.Evaluate(newXmlNavigator(document)).(type) {
+	case *xpath.NodeIterator:
+		if !result.MoveNext() {
+			return "", false, nil
+		}
+		return result.Current().Value(), true, nil
+	case float64:
+		return strconv.FormatFloat(result, 'f', -1, 64), true, nil
+	case bool:
+		return strconv.FormatBool(result), true, nil
+	case string:
+		return result, true, nil
+	default:
+		return "", false, errors.New(fmt.Sprintf("unsupported result [%T]", result))
+	}
+}
+
+// xmlNavigator lets the xpath package navigate through a parsed document
+type xmlNavigator struct {
+	root    *xmlNode
+	current *xmlNode
+	// index of the current attribute of the element, -1 if the navigator is not on an attribute
+	attribute int
+}
+
+func newXmlNavigator(root *xmlNode) *xmlNavigator {
+	return &xmlNavigator{root: root, current: root, attribute: -1}
+}
+
+func (n *xmlNavigator) NodeType() xpath.NodeType {
+	if n.attribute >= 0 {
+		return xpath.AttributeNode
+	}
+	return n.current.kind
+}
+
+func (n *xmlNavigator) LocalName() string {
+	if n.attribute >= 0 {
+		return n.current.attributes[n.attribute].Name.Local
+	}
+	return n.current.name.Local
+}
+
+// Prefix is always empty, the namespace is matched with NamespaceURL
+func (n *xmlNavigator) Prefix() string {
+	return ""
+}
+
+func (n *xmlNavigator) NamespaceURL() string {
+	if n.attribute >= 0 {
+		return n.current.attributes[n.attribute].Name.Space
+	}
+	return n.current.name.Space
+}
+
+func (n *xmlNavigator) Value() string {
+	if n.attribute >= 0 {
+		return n.current.attributes[n.attribute].Value
+	}
+	return n.current.value()
+}
+
+func (n *xmlNavigator) Copy() xpath.NodeNavigator {
+	c := *n
+	return &c
+}
+
+func (n *xmlNavigator) MoveToRoot() {
+	n.current = n.root
+	n.attribute = -1
+}
+
+func (n *xmlNavigator) MoveToParent() bool {
+	if n.attribute >= 0 {
+		n.attribute = -1
+		return true
+	}
+	if n.current.parent == nil {
+		return false
+	}
+	n.current = n.current.parent
+	return true
+}
+
+func (n *xmlNavigator) MoveToNextAttribute() bool {
+	if n.current.kind != xpath.ElementNode || n.attribute+1 >= len(n.current.attributes) {
+		return false
+	}
+	n.attribute++
+	return true
+}
+
+func (n *xmlNavigator) MoveToChild() bool {
+	if n.attribute >= 0 || len(n.current.children) == 0 {
+		return false
+	}
+	n.current = n.current.children[0]
+	return true
+}
+
+// MoveToFirst moves to the first sibling, it fails if the navigator already is on the first one
+func (n *xmlNavigator) MoveToFirst() bool {
+	if n.current.index == 0 {
+		return false
+	}
+	return n.moveToSibling(0)
+}
+
+func (n *xmlNavigator) MoveToNext() bool {
+	return n.moveToSibling(n.current.index + 1)
+}
+
+func (n *xmlNavigator) MoveToPrevious() bool {
+	return n.moveToSibling(n.current.index - 1)
+}
+
+func (n *xmlNavigator) moveToSibling(index int) bool {
+	if n.attribute >= 0 || n.current.parent == nil || index < 0 || index >= len(n.current.parent.children) {
+		return false
+	}
+	n.current = n.current.parent.children[index]
+	return true
+}
+
+func (n *xmlNavigator) MoveTo(other xpath.NodeNavigator) bool {
+	navigator, ok := other.(*xmlNavigator)
+	if !ok || navigator.root != n.root {
+		return false
+	}
+	*n = *navigator
+	return true
+}
@@ -0,0 +1,72 @@
+package validators
+
+import (
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"testing"
+)
+
+func TestXPath(t *testing.T) {
+	expressions := map[string]string{
+		"//ord:order/@id":                    "42",
+		"//ord:item[@sku='B-2']":             "Mouse & pad",
+		"count(//ord:item)":                  "2",
+		"/soap:Envelope/soap:Body/*/@status": "open",
+		"boolean(//ord:created)":             "true",
+		"//ord:created":                      "@ignore@",
+		// names without prefix match any namespace
+		"//order/item[1]": "Keyboard",
+	}
+	namespaces := map[string]string{
+		"soap": "http://schemas.xmlsoap.org/soap/envelope/",
+		"ord":  "http://example.com/orders",
+	}
+
+	if errs := validateXPath(expressions, namespaces, []byte(soapEnvelope)); errs != nil {
+		t.Errorf("No xpath validation error expected, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestXPathErrors(t *testing.T) {
+	expressions := map[string]string{
+		"//ord:order/@id": "7",
+		"//ord:missing":   "@ignore@",
+		"//ord:order[":    "",
+	}
+	namespaces := map[string]string{"ord": "http://example.com/orders"}
+
+	errs := validateXPath(expressions, namespaces, []byte(soapEnvelope))
+
+	expectedErrors := []string{
+		"xpath <//ord:missing> - no node found",
+		"xpath <//ord:order/@id> mismatch - expected [7] but received [42]",
+	}
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, but got %s", errors.Join(errs...))
+	}
+	for i, expected := range expectedErrors {
+		if errs[i].Error() != expected {
+			t.Errorf("Expected error [%s], but got [%s]", expected, errs[i])
+		}
+	}
+}
+
+func TestXPathWrongNamespace(t *testing.T) {
+	errs := validateXPath(map[string]string{"//ord:order/@id": "42"}, map[string]string{"ord": "urn:other"}, []byte(soapEnvelope))
+
+	if len(errs) != 1 {
+		t.Errorf("No node expected for another namespace, but got %s", errors.Join(errs...))
+	}
+}
+
+func TestValidateHttpBodyWithXPath(t *testing.T) {
+	expected := &model.ExpectedPayload{
+		Payload:     `<order id="1"/>`,
+		PayloadType: model.Xml,
+		XPath:       map[string]string{"/order/@id": "1"},
+	}
+
+	if err := ValidateHttpBody(expected, body(`<order id="1"/>`), logger); err != nil {
+		t.Errorf("No validation error expected, but got %s", err)
+	}
+}
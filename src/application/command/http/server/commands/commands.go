@@ -11,8 +11,60 @@ type InitEndpointCommand struct {
 	Port           uint
 	ContentType    string
 	TimeoutSeconds time.Duration
+	// UnmatchedRequests decides what happens to requests that no pending receive action matches
+	UnmatchedRequests UnmatchedRequestPolicy
+	// UnmatchedStatusCode is sent for rejected requests, default 404
+	UnmatchedStatusCode int
+	// JournalSize is the number of most recent requests kept in the journal, default 1000
+	JournalSize int
+	// Tls enables HTTPS when set
+	Tls *TlsConfig
+	// Http2 enables HTTP/2, negotiated over TLS or as cleartext h2c without TLS. HTTP/1.1 is always supported.
+	Http2 bool
 }
 
+// TlsConfig of the server. Relative file paths are resolved against the base directory of the agent.
+type TlsConfig struct {
+	CertFile string
+	KeyFile  string
+	// GenerateCertificate issues a certificate signed by the test CA of the agent, valid for localhost and Hosts
+	GenerateCertificate bool
+	Hosts               []string
+	// RequireClientCertificate enables mutual TLS, client certificates are verified
+	// with the CAs of the ClientCaFile, or with the test CA if not set
+	RequireClientCertificate bool
+	ClientCaFile             string
+	// ExportCaFile is where the certificate of the test CA is written to, so that the system under test can trust it
+	ExportCaFile string
+}
+
+type UnmatchedRequestPolicy int
+
+const (
+	// Queue keeps unmatched requests until a matching receive action is called or the action timeout is reached
+	Queue UnmatchedRequestPolicy = iota
+	// Reject answers unmatched requests immediately, so receive actions must be called before the requests arrive
+	Reject
+)
+
+// RequestMatcher selects the requests an action applies to. Empty fields match every request.
+type RequestMatcher struct {
+	Method string
+	// Path is compared exactly or is a path template like /orders/{id}/items/*,
+	// PathPattern is a regular expression the whole path must match
+	Path        string
+	PathPattern string
+	// Headers & QueryParams must be present in the request with the given values
+	Headers     map[string]string
+	QueryParams map[string][]string
+	// BodyContains is a substring & BodyPattern a regular expression the body must contain, only the beginning
+	// of large bodies is matched
+	BodyContains string
+	BodyPattern  string
+}
+
+// SendCommand may contain placeholders of session variables & template functions, like ${orderId} or ${uuid()},
+// in the header values & payload.
 type SendCommand struct {
 	Name         string
 	PayloadType  model.PayloadType
@@ -20,6 +72,56 @@ type SendCommand struct {
 	Headers      map[string]string
 	Payload      string
 	EndpointName string
+	// Matcher selects the received request to respond to, the oldest one if not set
+	Matcher *RequestMatcher
+	// Behaviour changes how the response is sent, it is sent immediately & completely if not set
+	Behaviour *ResponseBehaviour
+	// Binary replaces Payload for bodies that cannot be sent as strings
+	Binary *model.BinaryPayload
+	// ResolvePlaceholders replaces the placeholders of session variables & template functions in the Headers
+	// & Payload, like ${orderId} or ${uuid()}. The action is sent verbatim otherwise.
+	ResolvePlaceholders bool
+}
+
+// ResponseBehaviour changes how a response is sent, to test the resilience of the system under test
+type ResponseBehaviour struct {
+	// Delay before the response is sent, a random delay between Delay and MaxDelay if MaxDelay is set
+	Delay    time.Duration
+	MaxDelay time.Duration
+	// ChunkSize splits the payload into chunks of this many bytes, each sent after the ChunkDelay
+	ChunkSize  int
+	ChunkDelay time.Duration
+	// Fault replaces the response, the status, headers & payload are only used by some faults
+	Fault Fault
+}
+
+type Fault int
+
+const (
+	NoFault Fault = iota
+	// CloseBeforeHeaders closes the connection without sending anything
+	CloseBeforeHeaders
+	// EmptyResponse sends the status & headers with Content-Length 0, then closes the connection without the payload
+	EmptyResponse
+	// MalformedChunk sends the status & headers, followed by an invalid chunk of the chunked encoding
+	MalformedChunk
+	// ConnectionReset aborts the connection, which the client sees as connection reset by peer
+	ConnectionReset
+)
+
+func (f Fault) String() string {
+	switch f {
+	case CloseBeforeHeaders:
+		return "close before headers"
+	case EmptyResponse:
+		return "empty response"
+	case MalformedChunk:
+		return "malformed chunk"
+	case ConnectionReset:
+		return "connection reset"
+	default:
+		return "no fault"
+	}
 }
 
 type ReceiveCommand struct {
@@ -32,6 +134,161 @@ type ReceiveCommand struct {
 	Payload      string
 	PayloadType  model.PayloadType
 	EndpointName string
+	// Matcher selects the request to receive, the oldest one if not set
+	Matcher *RequestMatcher
+	// Protocol of the request, for example "HTTP/2.0", not validated if empty
+	Protocol string
+	// Binary replaces Payload for bodies that cannot be validated as strings, or are validated by checksum & size
+	Binary *model.BinaryPayload
+	// XPath maps XPath expressions to the expected value of their result, evaluated on XML payloads
+	XPath map[string]string
+	// Namespaces binds the prefixes used in the XPath expressions to namespace URIs
+	Namespaces map[string]string
+	// JsonPath assertions validate single values of a JSON payload, in addition to or instead of the whole payload
+	JsonPath []model.JsonPathAssertion
+	// JsonSchema validates the JSON payload, draft 2020-12 or any draft selected with $schema, like draft-07.
+	// JsonSchemaFile is used instead if set, relative paths are resolved against the base directory of the agent.
+	JsonSchema     string
+	JsonSchemaFile string
+	// Extractions save values of the received message as session variables, also if its validation failed
+	Extractions []model.Extraction
+	// SavePathVariables saves the segments captured by the path variables of the expected path as session variables.
+	// The expected path may be a template like /orders/{id}/items/* or /files/**.
+	SavePathVariables bool
+}
+
+// AddStubCommand registers a response that is sent automatically for every request matching the stub.
+// Stubs are only used for requests that no pending receive action matches; if several stubs match,
+// the one added last is used. A stub with the same name is replaced.
+type AddStubCommand struct {
+	Name         string
+	EndpointName string
+	Matcher      *RequestMatcher
+	StatusCode   int
+	Headers      map[string]string
+	Payload      string
+	// Behaviour changes how the response is sent, it is sent immediately & completely if not set
+	Behaviour *ResponseBehaviour
+	// Binary replaces Payload for bodies that cannot be sent as strings
+	Binary *model.BinaryPayload
+}
+
+type RemoveStubCommand struct {
+	Name         string
+	EndpointName string
+}
+
+// GetJournalCommand returns the journal entries of the requests matching the matcher, all of them if not set
+type GetJournalCommand struct {
+	Name         string
+	EndpointName string
+	Matcher      *RequestMatcher
+}
+
+// VerifyRequestCountCommand validates the number of requests in the journal that match the matcher.
+// Fewer requests are waited for until the timeout is reached, so that asynchronous requests can be verified.
+// The count is checked only once if no timeout is set.
+type VerifyRequestCountCommand struct {
+	Name         string
+	EndpointName string
+	Matcher      *RequestMatcher
+	Count        int
+	Timeout      time.Duration
+}
+
+// VerifyRequestOrderCommand validates that the journal contains requests matching the matchers in the given order.
+// Other requests may have been received in between.
+type VerifyRequestOrderCommand struct {
+	Name         string
+	EndpointName string
+	Matchers     []*RequestMatcher
+}
+
+// VerifyNoUnexpectedRequestsCommand fails if the journal contains requests that were
+// neither taken by a receive action nor answered by a stub.
+type VerifyNoUnexpectedRequestsCommand struct {
+	Name         string
+	EndpointName string
+}
+
+// JournalEntry is a request handled by the server, together with the response it was sent
+type JournalEntry struct {
+	Time     time.Time
+	Method   string
+	Url      string
+	Protocol string
+	Headers  map[string][]string
+	// Payload & ResponsePayload are only the beginning of large bodies
+	Payload string
+	// Received is set if the request was taken by a receive action
+	Received bool
+	// Stub is the name of the stub that answered the request
+	Stub string
+	// Completed is set once the response was sent, the response fields are empty otherwise
+	Completed bool
+	// StatusCode is 0 if the connection was taken over to inject a fault
+	StatusCode      int
+	ResponseHeaders map[string][]string
+	ResponsePayload string
+}
+
+type InitEndpointResult struct {
+	// CaCertificate is the PEM encoded certificate of the test CA, if the server uses it
+	CaCertificate string
+	Error         error
+}
+
+type SendResult struct {
+	Error error
+}
+
+type ReceiveResult struct {
+	// Request is set if a request was received, also if its validation failed
+	Request *ReceivedRequest
+	// Variables are the values saved by the extractions
+	Variables map[string]string
+	Error     error
+}
+
+// ReceivedRequest is the request taken by a receive action
+type ReceivedRequest struct {
+	Method      string
+	Url         string
+	Path        string
+	QueryParams map[string][]string
+	Protocol    string
+	Headers     map[string][]string
+	Payload     string
+	// PathVariables are the segments captured by the path variables of the expected path
+	PathVariables map[string]string
+	// PayloadTruncated is set if the payload is only the beginning of a large body
+	PayloadTruncated bool
+}
+
+type AddStubResult struct {
+	Error error
+}
+
+type RemoveStubResult struct {
+	Error error
+}
+
+type GetJournalResult struct {
+	Entries []*JournalEntry
+	Error   error
+}
+
+type VerifyRequestCountResult struct {
+	Count int
+	Error error
+}
+
+type VerifyRequestOrderResult struct {
+	Error error
+}
+
+type VerifyNoUnexpectedRequestsResult struct {
+	Error error
 }
 
 func (action *ReceiveCommand) ToString() string {
@@ -45,5 +302,19 @@ func (action *ReceiveCommand) ToString() string {
 			"Payload: %s"+
 			"]",
 		action.Method, action.Url, action.Path,
-		action.Headers, action.QueryParams, action.Payload)
+		action.Headers, action.QueryParams, model.DescribePayload(action.Payload, action.Binary))
+}
+
+// ExpectedPayload returns everything the received payload is validated against
+func (action *ReceiveCommand) ExpectedPayload() *model.ExpectedPayload {
+	return &model.ExpectedPayload{
+		Payload:        action.Payload,
+		PayloadType:    action.PayloadType,
+		Binary:         action.Binary,
+		XPath:          action.XPath,
+		Namespaces:     action.Namespaces,
+		JsonPath:       action.JsonPath,
+		JsonSchema:     action.JsonSchema,
+		JsonSchemaFile: action.JsonSchemaFile,
+	}
 }
@@ -1,18 +1,20 @@
 package internal
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/go-clarum/agent/application/command/http/common/constants"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
 	"github.com/go-clarum/agent/application/command/http/common/validators"
 	"github.com/go-clarum/agent/application/command/http/server/commands"
 	"github.com/go-clarum/agent/application/control"
 	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
 	"github.com/go-clarum/agent/infrastructure/config"
 	"github.com/go-clarum/agent/infrastructure/logging"
-	"io"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"net"
 	"net/http"
 	"time"
@@ -20,24 +22,31 @@ import (
 
 const contextNameKey = "endpointContext"
 
+const defaultUnmatchedStatusCode = http.StatusNotFound
+
 type Endpoint struct {
-	Name                     string
-	port                     uint
-	contentType              string
-	server                   *http.Server
-	serverTimeout            time.Duration
-	context                  context.Context
-	cancelContext            context.CancelFunc
-	requestValidationChannel chan *http.Request
-	sendChannel              chan *sendPair
-	logger                   *logging.Logger
+	Name                string
+	port                uint
+	contentType         string
+	server              *http.Server
+	tlsConfig           *tls.Config
+	http2               bool
+	caCertificate       string
+	serverTimeout       time.Duration
+	context             context.Context
+	cancelContext       context.CancelFunc
+	router              *router
+	journal             *journal
+	unmatchedStatusCode int
+	logger              *logging.Logger
 }
 
 type endpointContext struct {
-	endpointName             string
-	requestValidationChannel chan *http.Request
-	sendChannel              chan *sendPair
-	logger                   *logging.Logger
+	endpointName        string
+	router              *router
+	journal             *journal
+	unmatchedStatusCode int
+	logger              *logging.Logger
 }
 
 type sendPair struct {
@@ -45,50 +54,104 @@ type sendPair struct {
 	error    error
 }
 
-func NewEndpoint(is *commands.InitEndpointCommand) *Endpoint {
+func NewEndpoint(is *commands.InitEndpointCommand) (*Endpoint, error) {
+	logger := logging.NewLogger(loggerName(is.Name))
+
+	tlsConfig, caCertificate, err := newTlsConfig(is.Tls)
+	if err != nil {
+		logger.Errorf("invalid TLS configuration - %s", err)
+		return nil, errors.New(fmt.Sprintf("cannot create HTTP server endpoint [%s] - invalid TLS configuration - %s", is.Name, err))
+	}
+
 	ctx, cancelCtx := context.WithCancel(context.Background())
-	sendChannel := make(chan *sendPair)
-	requestChannel := make(chan *http.Request)
 
 	se := &Endpoint{
-		Name:                     is.Name,
-		port:                     is.Port,
-		contentType:              is.ContentType,
-		serverTimeout:            is.TimeoutSeconds,
-		context:                  ctx,
-		cancelContext:            cancelCtx,
-		sendChannel:              sendChannel,
-		requestValidationChannel: requestChannel,
-		logger:                   logging.NewLogger(loggerName(is.Name)),
-	}
-
-	return se
+		Name:                is.Name,
+		port:                is.Port,
+		contentType:         is.ContentType,
+		serverTimeout:       is.TimeoutSeconds,
+		tlsConfig:           tlsConfig,
+		http2:               is.Http2,
+		caCertificate:       caCertificate,
+		context:             ctx,
+		cancelContext:       cancelCtx,
+		router:              newRouter(is.UnmatchedRequests == commands.Queue),
+		journal:             newJournal(is.JournalSize),
+		unmatchedStatusCode: unmatchedStatusCode(is.UnmatchedStatusCode),
+		logger:              logger,
+	}
+
+	return se, nil
+}
+
+// CaCertificate returns the PEM encoded certificate of the test CA, if the server uses it
+func (endpoint *Endpoint) CaCertificate() string {
+	return endpoint.caCertificate
 }
 
-// this Method is blocking, until a request is received
-func (endpoint *Endpoint) Receive(action *commands.ReceiveCommand) (*http.Request, error) {
+// this Method is blocking, until a request matching the action is received
+func (endpoint *Endpoint) Receive(action *commands.ReceiveCommand) (*commands.ReceivedRequest, error) {
 	endpoint.logger.Debugf("action to receive %s", action.ToString())
 	endpoint.enrichReceiveAction(action)
 
-	select {
-	case receivedRequest := <-endpoint.requestValidationChannel:
-		endpoint.logger.Debugf("validation action %s", action.ToString())
-
-		return receivedRequest, errors.Join(
-			validators.ValidatePath(action.Path, receivedRequest.URL, endpoint.logger),
-			validators.ValidateHttpMethod(action.Method, receivedRequest.Method, endpoint.logger),
-			validators.ValidateHttpHeaders(action.Headers, receivedRequest.Header, endpoint.logger),
-			validators.ValidateHttpQueryParams(action.QueryParams, receivedRequest.URL, endpoint.logger),
-			validators.ValidateHttpPayload(&action.Payload, receivedRequest.Body,
-				action.PayloadType, endpoint.logger))
-	case <-time.After(config.ActionTimeout()):
-		return nil, endpoint.handleError("receive action timed out - no request received for validation", nil)
+	matcher, err := newRequestMatcher(action.Matcher)
+	if err != nil {
+		return nil, endpoint.handleError("receive action is invalid", err)
+	}
+
+	receivedExchange, pending := endpoint.router.receive(matcher)
+	if receivedExchange == nil {
+		endpoint.logger.Debugf("waiting for request matching %s", matcher)
+
+		select {
+		case receivedExchange = <-pending.exchange:
+		case <-time.After(config.ActionTimeout()):
+			if receivedExchange = endpoint.router.cancelReceive(pending); receivedExchange == nil {
+				return nil, endpoint.handleError(fmt.Sprintf("receive action timed out - no request matching %s received for validation",
+					matcher), nil)
+			}
+		case <-endpoint.context.Done():
+			endpoint.router.cancelReceive(pending)
+			return nil, endpoint.handleError("receive action canceled - endpoint was shut down", nil)
+		}
 	}
+
+	endpoint.logger.Debugf("validation action %s", action.ToString())
+	// the body of the request is streamed through the validation, only its beginning is kept in the exchange
+	receivedRequest := receivedExchange.request
+
+	pathVariables, pathErr := validators.ValidatePath(action.Path, receivedRequest.URL, endpoint.logger)
+	err = errors.Join(
+		validators.ValidateProtocol(action.Protocol, receivedRequest.Proto, endpoint.logger),
+		pathErr,
+		validators.ValidateHttpMethod(action.Method, receivedRequest.Method, endpoint.logger),
+		validators.ValidateHttpHeaders(action.Headers, receivedRequest.Header, endpoint.logger),
+		validators.ValidateHttpQueryParams(action.QueryParams, receivedRequest.URL, endpoint.logger),
+		validators.ValidateHttpBody(action.ExpectedPayload(), receivedRequest.Body, endpoint.logger))
+
+	return &commands.ReceivedRequest{
+		Method:           receivedRequest.Method,
+		Url:              receivedRequest.URL.String(),
+		Path:             receivedRequest.URL.Path,
+		QueryParams:      receivedRequest.URL.Query(),
+		Protocol:         receivedRequest.Proto,
+		Headers:          receivedRequest.Header.Clone(),
+		Payload:          string(receivedExchange.body),
+		PayloadTruncated: receivedExchange.bodyTruncated,
+		PathVariables:    pathVariables,
+	}, err
 }
 
+// Send responds to the oldest received request that matches the action.
+// This Method is blocking, until such a request has been received.
 func (endpoint *Endpoint) Send(action *commands.SendCommand) error {
 	endpoint.enrichSendAction(action)
-	err := endpoint.validateMessageToSend(action)
+
+	matcher, err := newRequestMatcher(action.Matcher)
+	if err != nil {
+		return endpoint.handleError("send action is invalid", err)
+	}
+	err = endpoint.validateMessageToSend(action)
 
 	// we must always send a signal downstream so that the handler is not blocked
 	toSend := &sendPair{
@@ -96,15 +159,66 @@ func (endpoint *Endpoint) Send(action *commands.SendCommand) error {
 		error:    err,
 	}
 
-	select {
-	case endpoint.sendChannel <- toSend:
+	timeout := time.After(config.ActionTimeout())
+	for {
+		receivedExchange, receivedChanged := endpoint.router.respond(matcher)
+		if receivedExchange != nil {
+			receivedExchange.response <- toSend
+			return err
+		}
+
+		select {
+		case <-receivedChanged:
+		case <-timeout:
+			return endpoint.handleError(fmt.Sprintf("send action timed out - no received request matching %s to respond to",
+				matcher), nil)
+		case <-endpoint.context.Done():
+			return endpoint.handleError("send action canceled - endpoint was shut down", nil)
+		}
+	}
+}
+
+// AddStub registers a response that is sent automatically for every request matching the stub
+func (endpoint *Endpoint) AddStub(action *commands.AddStubCommand) error {
+	if clarumstrings.IsBlank(action.Name) {
+		return endpoint.handleError("stub is invalid - name is empty", nil)
+	}
+
+	matcher, err := newRequestMatcher(action.Matcher)
+	if err != nil {
+		return endpoint.handleError(fmt.Sprintf("stub [%s] is invalid", action.Name), err)
+	}
+
+	newStub := newStub(action, matcher)
+	endpoint.enrichSendAction(newStub.response)
+	if err := endpoint.validateMessageToSend(newStub.response); err != nil {
 		return err
-	case <-time.After(config.ActionTimeout()):
-		return endpoint.handleError("send action timed out - no request received for validation", nil)
 	}
+
+	if endpoint.router.addStub(newStub) {
+		endpoint.logger.Infof("replaced stub [%s] for requests matching %s", newStub.name, matcher)
+	} else {
+		endpoint.logger.Infof("added stub [%s] for requests matching %s", newStub.name, matcher)
+	}
+
+	return nil
+}
+
+func (endpoint *Endpoint) RemoveStub(action *commands.RemoveStubCommand) error {
+	if !endpoint.router.removeStub(action.Name) {
+		return endpoint.handleError(fmt.Sprintf("stub [%s] not found", action.Name), nil)
+	}
+
+	endpoint.logger.Infof("removed stub [%s]", action.Name)
+	return nil
 }
 
 func (endpoint *Endpoint) enrichReceiveAction(action *commands.ReceiveCommand) {
+	// if no Headers have been sent by the bindings, this will be nil
+	if action.Headers == nil {
+		action.Headers = make(map[string]string)
+	}
+
 	if clarumstrings.IsNotBlank(endpoint.contentType) {
 		if _, exists := action.Headers[constants.ContentTypeHeaderName]; !exists {
 			action.Headers[constants.ContentTypeHeaderName] = endpoint.contentType
@@ -123,28 +237,41 @@ func (endpoint *Endpoint) enrichSendAction(action *commands.SendCommand) {
 	}
 }
 
-func (endpoint *Endpoint) Start() {
+// Start binds the port before it returns, so that a port already in use is reported to the caller.
+// The requests are served in the background.
+func (endpoint *Endpoint) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", requestHandler)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", endpoint.port),
-		Handler:      mux,
+		Handler:      endpoint.protocolHandler(mux),
 		WriteTimeout: endpoint.serverTimeout,
+		TLSConfig:    endpoint.serverTlsConfig(),
 		BaseContext: func(l net.Listener) context.Context {
 			endpointContext := &endpointContext{
-				endpointName:             endpoint.Name,
-				requestValidationChannel: endpoint.requestValidationChannel,
-				sendChannel:              endpoint.sendChannel,
-				logger:                   endpoint.logger,
+				endpointName:        endpoint.Name,
+				router:              endpoint.router,
+				journal:             endpoint.journal,
+				unmatchedStatusCode: endpoint.unmatchedStatusCode,
+				logger:              endpoint.logger,
 			}
 
 			return context.WithValue(endpoint.context, contextNameKey, endpointContext)
 		},
 	}
 
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		endpoint.cancelContext()
+		return endpoint.handleError("unable to start server", err)
+	}
+	if server.TLSConfig != nil {
+		listener = tls.NewListener(listener, server.TLSConfig)
+	}
+
 	go func() {
-		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
 			endpoint.logger.Errorf("error - %s", err)
 		} else {
 			endpoint.logger.Info("closed server")
@@ -154,29 +281,85 @@ func (endpoint *Endpoint) Start() {
 	}()
 
 	endpoint.server = server
+	return nil
+}
+
+// serverTlsConfig announces the protocols of the server with ALPN, which ListenAndServeTLS would do for us.
+// The server only serves HTTP/2 over TLS if its config announces "h2".
+func (endpoint *Endpoint) serverTlsConfig() *tls.Config {
+	if endpoint.tlsConfig == nil {
+		return nil
+	}
+
+	config := endpoint.tlsConfig.Clone()
+	if endpoint.http2 {
+		config.NextProtos = []string{"h2", "http/1.1"}
+	} else {
+		config.NextProtos = []string{"http/1.1"}
+	}
+
+	return config
+}
+
+// HTTP/2 over TLS is negotiated by the server itself, cleartext HTTP/2 needs the h2c handler
+func (endpoint *Endpoint) protocolHandler(handler http.Handler) http.Handler {
+	if endpoint.http2 && endpoint.tlsConfig == nil {
+		return h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	return handler
 }
 
 // The requestHandler is started when the server receives a request.
-// The request is sent to the requestValidationChannel to be picked up by a test action (validation).
-// After sending the request to the channel, the handler is blocked until the send() test action
+// The request is routed to a receive test action that matches it (validation), answered by a matching stub,
+// or queued until a matching receive test action is called. Every request is recorded in the journal.
+// After the request was received, the handler is blocked until a send() test action
 // provides a response message. This way we can control, inside the test, when a response will be sent.
 // The handler blocks until a timeout is triggered
-func requestHandler(resWriter http.ResponseWriter, request *http.Request) {
+func requestHandler(writer http.ResponseWriter, request *http.Request) {
 	control.RunningActions.Add(1)
 	ctx := request.Context().Value(contextNameKey).(*endpointContext)
 	defer finishOrRecover(ctx.logger)
 
-	logIncomingRequest(ctx.logger, request)
+	body, err := readBody(request)
+	if err != nil {
+		sendDefaultErrorResponse(ctx.logger, fmt.Sprintf("could not read request body - %s", err), writer)
+		return
+	}
+	logIncomingRequest(ctx.logger, request, body)
+
+	resWriter := newRecordingWriter(writer)
+	receivedExchange := newExchange(request, body)
+	journalRecord := ctx.journal.record(receivedExchange)
+
+	matchingStub, routed := ctx.router.route(receivedExchange)
+	if matchingStub != nil {
+		defer ctx.journal.complete(journalRecord, matchingStub.name, resWriter)
+		ctx.logger.Debugf("request matches stub [%s]", matchingStub.name)
+		sendResponse(ctx.logger, matchingStub.response, request, resWriter)
+		return
+	}
+
+	defer ctx.journal.complete(journalRecord, "", resWriter)
+	if !routed {
+		sendUnmatchedResponse(ctx.logger, ctx.unmatchedStatusCode, resWriter)
+		return
+	}
+	defer ctx.router.remove(receivedExchange)
 
 	select {
-	case ctx.requestValidationChannel <- request:
-		ctx.logger.Debug("received request was sent to validation channel")
+	case <-receivedExchange.claimed:
+		ctx.logger.Debug("received request was taken by a receive action")
 	case <-time.After(config.ActionTimeout()):
-		ctx.logger.Warn("request handling timed out - no server receive action called in test")
+		ctx.logger.Warn("request handling timed out - no matching server receive action called in test")
+		return
+	case <-request.Context().Done():
+		ctx.logger.Warn("request handling canceled - request context is done")
+		return
 	}
 
 	select {
-	case sendPair := <-ctx.sendChannel:
+	case sendPair := <-receivedExchange.response:
 		// error from upstream - we send a response to close the HTTP cycle
 		if sendPair.error != nil {
 			sendDefaultErrorResponse(ctx.logger, "request handler received error from upstream", resWriter)
@@ -189,9 +372,11 @@ func requestHandler(resWriter http.ResponseWriter, request *http.Request) {
 			return
 		}
 
-		sendResponse(ctx.logger, sendPair, resWriter)
+		sendResponse(ctx.logger, sendPair.response, request, resWriter)
 	case <-time.After(config.ActionTimeout()):
 		ctx.logger.Warn("response handling timed out - no server send action called in test")
+	case <-request.Context().Done():
+		ctx.logger.Warn("response handling canceled - request context is done")
 	}
 }
 
@@ -219,18 +404,10 @@ func (endpoint *Endpoint) Shutdown() {
 	}
 }
 
-func sendResponse(logger *logging.Logger, sendPair *sendPair, resWriter http.ResponseWriter) {
-	for header, value := range sendPair.response.Headers {
-		resWriter.Header().Set(header, value)
-	}
-
-	resWriter.WriteHeader(sendPair.response.StatusCode)
-
-	_, err := io.WriteString(resWriter, sendPair.response.Payload)
-	if err != nil {
-		logger.Errorf("could not write response body - %s", err)
-	}
-	logOutgoingResponse(logger, sendPair.response.StatusCode, sendPair.response.Payload, resWriter)
+func sendUnmatchedResponse(logger *logging.Logger, statusCode int, resWriter http.ResponseWriter) {
+	logger.Warnf("no receive action matches the request - rejecting it with status [%d]", statusCode)
+	resWriter.WriteHeader(statusCode)
+	logOutgoingResponse(logger, statusCode, "", resWriter)
 }
 
 func sendDefaultErrorResponse(logger *logging.Logger, errorMessage string, resWriter http.ResponseWriter) {
@@ -244,6 +421,17 @@ func (endpoint *Endpoint) validateMessageToSend(action *commands.SendCommand) er
 		return endpoint.handleError(fmt.Sprintf("action to send is invalid - unsupported status code [%d]",
 			action.StatusCode), nil)
 	}
+	if err := validateBehaviour(action.Behaviour); err != nil {
+		return endpoint.handleError("action to send is invalid", err)
+	}
+	if action.Binary != nil {
+		if clarumstrings.IsNotBlank(action.Payload) {
+			return endpoint.handleError("action to send is invalid - both a payload and a binary payload are set", nil)
+		}
+		if err := action.Binary.ValidateToSend(); err != nil {
+			return endpoint.handleError("action to send is invalid", err)
+		}
+	}
 
 	return nil
 }
@@ -263,31 +451,34 @@ func finishOrRecover(logger *logging.Logger) {
 	control.RunningActions.Done()
 
 	if r := recover(); r != nil {
+		// used on purpose to abort the response, so the server must handle it
+		if r == http.ErrAbortHandler {
+			panic(r)
+		}
 		logger.Errorf("endpoint panicked: error - %s", r)
 	}
 }
 
-// we read the body 'as is' for logging, after which we put it back into the request
-// with an open reader so that it can be read downstream again
-func logIncomingRequest(logger *logging.Logger, request *http.Request) {
-	bodyBytes, _ := io.ReadAll(request.Body)
-	bodyString := ""
-
-	err := request.Body.Close()
+// we read the beginning of the body 'as is' for matching, logging & the journal, after which we put it back
+// in front of the rest of the request body, so that it can be read downstream again. Large payloads are streamed this way.
+func readBody(request *http.Request) (*utils.Capture, error) {
+	body, previewed, err := utils.PreviewBody(request.Body, utils.MaxCapturedPayloadSize)
 	if err != nil {
-		logger.Errorf("could not read request body - %s", err)
-	} else {
-		request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-		bodyString = string(bodyBytes)
+		return nil, err
 	}
 
+	request.Body = previewed
+	return body, nil
+}
+
+func logIncomingRequest(logger *logging.Logger, request *http.Request, body *utils.Capture) {
 	logger.Infof("received HTTP request ["+
 		"method: %s, "+
 		"url: %s, "+
 		"headers: %s, "+
 		"payload: %s"+
 		"]",
-		request.Method, request.URL.String(), request.Header, bodyString)
+		request.Method, request.URL.String(), request.Header, utils.LoggablePayload(body.Bytes(), body.Truncated()))
 }
 
 func logOutgoingResponse(logger *logging.Logger, statusCode int, payload string, res http.ResponseWriter) {
@@ -299,6 +490,13 @@ func logOutgoingResponse(logger *logging.Logger, statusCode int, payload string,
 		statusCode, res.Header(), payload)
 }
 
+func unmatchedStatusCode(statusCode int) int {
+	if statusCode > 0 {
+		return statusCode
+	}
+	return defaultUnmatchedStatusCode
+}
+
 func loggerName(endpointName string) string {
 	return fmt.Sprintf("%s:", endpointName)
 }
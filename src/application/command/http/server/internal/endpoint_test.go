@@ -0,0 +1,358 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartFailsIfPortIsInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	endpoint, err := NewEndpoint(&commands.InitEndpointCommand{Name: "server", Port: uint(listener.Addr().(*net.TCPAddr).Port)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := endpoint.Start(); err == nil || !strings.Contains(err.Error(), "address already in use") {
+		t.Errorf("expected port in use error, but got %v", err)
+	}
+}
+
+func TestConcurrentRequestsAreRoutedByMatcher(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	paths := []string{"/orders", "/users", "/payments"}
+
+	var wg sync.WaitGroup
+	responses := make(map[string]string)
+	var lock sync.Mutex
+	for _, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, body := testRequest(t, endpoint, "GET", path)
+			lock.Lock()
+			responses[path] = fmt.Sprintf("%d %s", status, body)
+			lock.Unlock()
+		}()
+	}
+
+	// receive & answer the requests in the reverse order, independent of the order they arrived in
+	for i := len(paths) - 1; i >= 0; i-- {
+		matcher := &commands.RequestMatcher{Path: paths[i]}
+		if _, err := endpoint.Receive(&commands.ReceiveCommand{Method: "GET", Path: []string{paths[i]}, Matcher: matcher}); err != nil {
+			t.Errorf("no receive error expected, but got %s", err)
+		}
+		if err := endpoint.Send(&commands.SendCommand{StatusCode: 200, Payload: paths[i], Matcher: matcher}); err != nil {
+			t.Errorf("no send error expected, but got %s", err)
+		}
+	}
+	wg.Wait()
+
+	for _, path := range paths {
+		if responses[path] != "200 "+path {
+			t.Errorf("unexpected response for %s - %s", path, responses[path])
+		}
+	}
+}
+
+func TestRejectUnmatchedRequests(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{
+		Name:                "server",
+		UnmatchedRequests:   commands.Reject,
+		UnmatchedStatusCode: http.StatusTeapot,
+	})
+
+	if status, _ := testRequest(t, endpoint, "GET", "/health"); status != http.StatusTeapot {
+		t.Errorf("expected unmatched request to be rejected, but got status %d", status)
+	}
+
+	done := make(chan int)
+	go func() {
+		_, err := endpoint.Receive(&commands.ReceiveCommand{
+			Method:  "POST",
+			Path:    []string{"orders"},
+			Matcher: &commands.RequestMatcher{Method: "POST"},
+		})
+		if err != nil {
+			t.Errorf("no receive error expected, but got %s", err)
+		}
+		_ = endpoint.Send(&commands.SendCommand{StatusCode: 201})
+	}()
+	go func() {
+		status := 0
+		// the receive action may not be registered yet, so we retry rejected requests
+		for status != http.StatusCreated && status != http.StatusInternalServerError {
+			time.Sleep(10 * time.Millisecond)
+			status, _ = testRequest(t, endpoint, "POST", "/orders")
+		}
+		done <- status
+	}()
+
+	if status := <-done; status != http.StatusCreated {
+		t.Errorf("expected matching request to be answered, but got status %d", status)
+	}
+}
+
+func TestInvalidReceiveMatcher(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{Name: "server"})
+
+	_, err := endpoint.Receive(&commands.ReceiveCommand{Matcher: &commands.RequestMatcher{PathPattern: "("}})
+	if err == nil || !strings.HasPrefix(err.Error(), "server: receive action is invalid") {
+		t.Errorf("expected invalid matcher error, but got %s", err)
+	}
+}
+
+func startTestEndpoint(t *testing.T, ic *commands.InitEndpointCommand) *Endpoint {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic.Port = uint(listener.Addr().(*net.TCPAddr).Port)
+	_ = listener.Close()
+
+	endpoint, err := NewEndpoint(ic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := endpoint.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(endpoint.Shutdown)
+
+	return endpoint
+}
+
+func testRequest(t *testing.T, endpoint *Endpoint, method string, path string) (int, string) {
+	request, _ := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", endpoint.port, path), nil)
+
+	for {
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			// the server is started in the background
+			if strings.Contains(err.Error(), "connection refused") {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			t.Errorf("request failed - %s", err)
+			return 0, ""
+		}
+
+		body, _ := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		return response.StatusCode, string(body)
+	}
+}
+
+func TestStubs(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server", UnmatchedRequests: commands.Reject})
+
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: 200, Payload: "default"})
+	addStub(t, endpoint, &commands.AddStubCommand{
+		Name:       "health",
+		Matcher:    &commands.RequestMatcher{Path: "/health"},
+		StatusCode: 200,
+		Payload:    "UP",
+		Headers:    map[string]string{"X-Stub": "health"},
+	})
+
+	// stubs are served repeatedly & the one added last wins
+	for i := 0; i < 3; i++ {
+		if status, body := testRequest(t, endpoint, "GET", "/health"); status != 200 || body != "UP" {
+			t.Errorf("unexpected stub response %d %s", status, body)
+		}
+	}
+	if _, body := testRequest(t, endpoint, "GET", "/other"); body != "default" {
+		t.Errorf("expected fallback stub to answer, but got %s", body)
+	}
+
+	if err := endpoint.RemoveStub(&commands.RemoveStubCommand{Name: "any"}); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+	if status, _ := testRequest(t, endpoint, "GET", "/other"); status != http.StatusNotFound {
+		t.Errorf("expected request to be rejected after removing the stub, but got %d", status)
+	}
+	if err := endpoint.RemoveStub(&commands.RemoveStubCommand{Name: "any"}); err == nil {
+		t.Errorf("error expected for unknown stub")
+	}
+
+	entries := endpoint.journal.entries(nil)
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 journal entries, got %d", len(entries))
+	}
+	if entries[0].Stub != "health" || entries[0].ResponsePayload != "UP" || http.Header(entries[0].ResponseHeaders).Get("X-Stub") != "health" {
+		t.Errorf("unexpected journal entry %+v", entries[0])
+	}
+	if entries[4].Stub != "" || entries[4].StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected journal entry %+v", entries[4])
+	}
+}
+
+func TestReceiveActionTakesPrecedenceOverStub(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "orders", StatusCode: 200,
+		Behaviour: &commands.ResponseBehaviour{Delay: 100 * time.Millisecond}})
+
+	done := make(chan int)
+	go func() {
+		// wait for the receive action to be registered
+		time.Sleep(100 * time.Millisecond)
+		status, _ := testRequest(t, endpoint, "POST", "/orders")
+		done <- status
+	}()
+
+	if _, err := endpoint.Receive(&commands.ReceiveCommand{Method: "POST", Path: []string{"orders"}}); err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+	if err := endpoint.Send(&commands.SendCommand{StatusCode: 202}); err != nil {
+		t.Errorf("no send error expected, but got %s", err)
+	}
+	if status := <-done; status != 202 {
+		t.Errorf("expected the receive action to handle the request, but got status %d", status)
+	}
+
+	start := time.Now()
+	if status, _ := testRequest(t, endpoint, "POST", "/orders"); status != 200 {
+		t.Errorf("expected the stub to handle the request, but got status %d", status)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected the stub response to be delayed, but it took %s", elapsed)
+	}
+}
+
+func TestReceivedRequestIsReturned(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+
+	go func() {
+		// wait for the server to start
+		time.Sleep(100 * time.Millisecond)
+		request, _ := http.NewRequest("PUT", fmt.Sprintf("http://localhost:%d/orders/42?expand=items", endpoint.port),
+			strings.NewReader(`{"id": 42}`))
+		request.Header.Set("If-Match", `"v1"`)
+		if response, err := http.DefaultClient.Do(request); err == nil {
+			_ = response.Body.Close()
+		}
+	}()
+
+	// the request is returned even if its validation failed
+	received, err := endpoint.Receive(&commands.ReceiveCommand{Method: "PUT", Path: []string{"orders", "43"}})
+	_ = endpoint.Send(&commands.SendCommand{StatusCode: 200})
+
+	if err == nil {
+		t.Errorf("path mismatch expected")
+	}
+	if received == nil {
+		t.Fatalf("expected the received request")
+	}
+	if received.Method != "PUT" || received.Path != "/orders/42" || received.Url != "/orders/42?expand=items" {
+		t.Errorf("unexpected request line [%s %s %s]", received.Method, received.Path, received.Url)
+	}
+	if values := received.QueryParams["expand"]; len(values) != 1 || values[0] != "items" {
+		t.Errorf("unexpected query params %v", received.QueryParams)
+	}
+	if values := received.Headers["If-Match"]; len(values) != 1 || values[0] != `"v1"` {
+		t.Errorf("unexpected headers %v", received.Headers)
+	}
+	if received.Payload != `{"id": 42}` || received.Protocol != "HTTP/1.1" {
+		t.Errorf("unexpected payload [%s] or protocol [%s]", received.Payload, received.Protocol)
+	}
+}
+
+func TestLargePayloadsAreStreamed(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	content := bytes.Repeat([]byte{0x25, 0x50, 0x44, 0x46, 0x00, 0xff}, utils.MaxCapturedPayloadSize)
+	digest := sha256.Sum256(content)
+	size := int64(len(content))
+
+	go func() {
+		// wait for the server to start
+		time.Sleep(100 * time.Millisecond)
+		url := fmt.Sprintf("http://localhost:%d/upload", endpoint.port)
+		if response, err := http.Post(url, "application/pdf", bytes.NewReader(content)); err == nil {
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+	}()
+
+	// the whole request body is validated, only its beginning is kept
+	received, err := endpoint.Receive(&commands.ReceiveCommand{Method: "POST", Path: []string{"upload"},
+		Binary: &model.BinaryPayload{Checksum: "sha256:" + hex.EncodeToString(digest[:]), Size: &size}})
+	if err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+	if received == nil || len(received.Payload) != utils.MaxCapturedPayloadSize || !received.PayloadTruncated {
+		t.Fatalf("expected a truncated payload")
+	}
+	if err := endpoint.Send(&commands.SendCommand{StatusCode: 200, Binary: &model.BinaryPayload{Bytes: content}}); err != nil {
+		t.Errorf("no send error expected, but got %s", err)
+	}
+
+	// the journal is completed once the response was sent
+	var entries []*commands.JournalEntry
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+		if entries, _ = endpoint.Journal(&commands.GetJournalCommand{}); len(entries) == 1 && entries[0].Completed {
+			break
+		}
+	}
+	if len(entries) != 1 || len(entries[0].Payload) != utils.MaxCapturedPayloadSize ||
+		len(entries[0].ResponsePayload) != utils.MaxCapturedPayloadSize {
+		t.Errorf("expected the journal to keep the beginning of the payloads only")
+	}
+}
+
+func TestReceivePathTemplate(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+
+	go func() {
+		// wait for the receive action to be registered
+		time.Sleep(100 * time.Millisecond)
+		testRequest(t, endpoint, "GET", "/orders/42/items/7")
+	}()
+
+	received, err := endpoint.Receive(&commands.ReceiveCommand{Method: "GET", Path: []string{"/orders/{id}/items/*"},
+		Matcher: &commands.RequestMatcher{Path: "/orders/{id}/**"}})
+	_ = endpoint.Send(&commands.SendCommand{StatusCode: 200})
+
+	if err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+	if received == nil || received.PathVariables["id"] != "42" {
+		t.Errorf("expected the path variable <id> = [42], but got %v", received)
+	}
+}
+
+func TestInvalidStub(t *testing.T) {
+	endpoint, _ := NewEndpoint(&commands.InitEndpointCommand{Name: "server"})
+
+	if err := endpoint.AddStub(&commands.AddStubCommand{StatusCode: 200}); err == nil {
+		t.Errorf("error expected for stub without name")
+	}
+	if err := endpoint.AddStub(&commands.AddStubCommand{Name: "stub", StatusCode: 42}); err == nil {
+		t.Errorf("error expected for invalid status code")
+	}
+	if err := endpoint.AddStub(&commands.AddStubCommand{Name: "stub", StatusCode: 200,
+		Matcher: &commands.RequestMatcher{BodyPattern: "("}}); err == nil {
+		t.Errorf("error expected for invalid matcher")
+	}
+}
+
+func addStub(t *testing.T, endpoint *Endpoint, action *commands.AddStubCommand) {
+	if err := endpoint.AddStub(action); err != nil {
+		t.Fatalf("no error expected when adding stub, but got %s", err)
+	}
+}
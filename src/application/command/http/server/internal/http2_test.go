@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/certificates"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestH2c(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server", Http2: true})
+	h2cClient := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network string, addr string, _ *tls.Config) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}}
+
+	go func() {
+		_ = protocolRequest(t, h2cClient, fmt.Sprintf("http://localhost:%d/h2c", endpoint.port))
+	}()
+
+	if _, err := endpoint.Receive(&commands.ReceiveCommand{Method: "GET", Path: []string{"h2c"}, Protocol: "2"}); err != nil {
+		t.Errorf("no receive error expected, but got %s", err)
+	}
+	if err := endpoint.Send(&commands.SendCommand{StatusCode: 200}); err != nil {
+		t.Errorf("no send error expected, but got %s", err)
+	}
+
+	entries, _ := endpoint.Journal(&commands.GetJournalCommand{})
+	if len(entries) != 1 || entries[0].Protocol != "HTTP/2.0" {
+		t.Errorf("expected the journal to contain the protocol, got %+v", entries)
+	}
+}
+
+func TestHttp2OverTls(t *testing.T) {
+	ca, _ := certificates.TestCA()
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: ca.CertPool()},
+		ForceAttemptHTTP2: true,
+	}}
+
+	for _, enabled := range []bool{true, false} {
+		endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{
+			Name:  "server",
+			Http2: enabled,
+			Tls:   &commands.TlsConfig{GenerateCertificate: true},
+		})
+		addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: 200})
+
+		expected := "HTTP/1.1"
+		if enabled {
+			expected = "HTTP/2.0"
+		}
+		if protocol := protocolRequest(t, client, fmt.Sprintf("https://localhost:%d/", endpoint.port)); protocol != expected {
+			t.Errorf("expected %s with HTTP/2 enabled [%t], but got %s", expected, enabled, protocol)
+		}
+	}
+}
+
+func protocolRequest(t *testing.T, client *http.Client, url string) string {
+	for {
+		response, err := client.Get(url)
+		if err != nil {
+			// the server is started in the background
+			if strings.Contains(err.Error(), "connection refused") {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			t.Errorf("request failed - %s", err)
+			return ""
+		}
+
+		_ = response.Body.Close()
+		return response.Proto
+	}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bufio"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const defaultJournalSize = 1000
+
+// journalRecord keeps the exchange for matching, next to the entry returned to the bindings
+type journalRecord struct {
+	exchange *exchange
+	entry    commands.JournalEntry
+}
+
+// journal keeps the most recent requests handled by the server in the order they arrived
+type journal struct {
+	lock     sync.RWMutex
+	size     int
+	recorded []*journalRecord
+}
+
+func newJournal(size int) *journal {
+	if size <= 0 {
+		size = defaultJournalSize
+	}
+
+	return &journal{size: size}
+}
+
+func (j *journal) record(ex *exchange) *journalRecord {
+	record := &journalRecord{
+		exchange: ex,
+		entry: commands.JournalEntry{
+			Time:     time.Now(),
+			Method:   ex.request.Method,
+			Url:      ex.request.URL.String(),
+			Protocol: ex.request.Proto,
+			Headers:  ex.request.Header.Clone(),
+			Payload:  string(ex.body),
+		},
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.recorded = append(j.recorded, record)
+	if len(j.recorded) > j.size {
+		j.recorded = j.recorded[len(j.recorded)-j.size:]
+	}
+
+	return record
+}
+
+// complete the record with the response the handler wrote. Requests the handler did
+// not write a response for (timeouts) are answered with 200 by the server.
+func (j *journal) complete(record *journalRecord, stubName string, response *recordingWriter) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	record.entry.Stub = stubName
+	record.entry.Completed = true
+	record.entry.StatusCode = response.statusCode
+	if record.entry.StatusCode == 0 && !response.hijacked {
+		record.entry.StatusCode = http.StatusOK
+	}
+	record.entry.ResponseHeaders = response.Header().Clone()
+	record.entry.ResponsePayload = string(response.body.Bytes())
+}
+
+// entries returns a copy of the entries of the requests matching the matcher, oldest first
+func (j *journal) entries(matcher *requestMatcher) []*commands.JournalEntry {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	var result []*commands.JournalEntry
+	for _, record := range j.recorded {
+		if matcher.matches(record.exchange) {
+			entry := record.entry
+			entry.Received = record.exchange.wasClaimed()
+			result = append(result, &entry)
+		}
+	}
+
+	return result
+}
+
+// records returns a copy of the journal records, oldest first
+func (j *journal) records() []*journalRecord {
+	j.lock.RLock()
+	defer j.lock.RUnlock()
+
+	return slices.Clone(j.recorded)
+}
+
+func newRecordingWriter(writer http.ResponseWriter) *recordingWriter {
+	return &recordingWriter{
+		ResponseWriter: writer,
+		body:           utils.NewCapture(utils.MaxCapturedPayloadSize),
+	}
+}
+
+// recordingWriter keeps a copy of the beginning of the response written by the handler for the journal
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	// body is the beginning of the response body, large responses are not kept in memory
+	body     *utils.Capture
+	hijacked bool
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Add(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack is used to inject faults, the response is no longer written by the handler afterward
+func (w *recordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buffer, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	w.hijacked = err == nil
+	return conn, buffer, err
+}
+
+// Unwrap gives http.ResponseController access to the other features of the writer, like Flush
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
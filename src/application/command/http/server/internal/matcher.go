@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/validators"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"github.com/go-clarum/agent/application/utils/arrays"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"regexp"
+	"strings"
+)
+
+// requestMatcher is the compiled form of a commands.RequestMatcher. A nil matcher matches every request.
+type requestMatcher struct {
+	definition   *commands.RequestMatcher
+	pathTemplate *validators.PathTemplate
+	pathPattern  *regexp.Regexp
+	bodyPattern  *regexp.Regexp
+}
+
+func newRequestMatcher(definition *commands.RequestMatcher) (*requestMatcher, error) {
+	if definition == nil {
+		return nil, nil
+	}
+
+	matcher := &requestMatcher{definition: definition}
+	var err error
+
+	if clarumstrings.IsNotBlank(definition.Path) {
+		if matcher.pathTemplate, err = validators.NewPathTemplate(definition.Path); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid %s", err))
+		}
+	}
+	if clarumstrings.IsNotBlank(definition.PathPattern) {
+		if matcher.pathPattern, err = regexp.Compile("^(?:" + definition.PathPattern + ")$"); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid path pattern - %s", err))
+		}
+	}
+	if clarumstrings.IsNotBlank(definition.BodyPattern) {
+		if matcher.bodyPattern, err = regexp.Compile(definition.BodyPattern); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid body pattern - %s", err))
+		}
+	}
+
+	return matcher, nil
+}
+
+func (matcher *requestMatcher) matches(ex *exchange) bool {
+	if matcher == nil {
+		return true
+	}
+
+	return matcher.matchesMethod(ex) &&
+		matcher.matchesPath(ex) &&
+		matcher.matchesHeaders(ex) &&
+		matcher.matchesQueryParams(ex) &&
+		matcher.matchesBody(ex)
+}
+
+func (matcher *requestMatcher) matchesMethod(ex *exchange) bool {
+	return clarumstrings.IsBlank(matcher.definition.Method) ||
+		strings.EqualFold(matcher.definition.Method, ex.request.Method)
+}
+
+// the path pattern is matched against the path with the leading "/"
+func (matcher *requestMatcher) matchesPath(ex *exchange) bool {
+	if matcher.pathTemplate != nil {
+		if _, matched := matcher.pathTemplate.Match(ex.request.URL.Path); !matched {
+			return false
+		}
+	}
+
+	return matcher.pathPattern == nil || matcher.pathPattern.MatchString(ex.request.URL.Path)
+}
+
+// the server canonicalizes the header names of received requests, so Values() is case-insensitive
+func (matcher *requestMatcher) matchesHeaders(ex *exchange) bool {
+	for header, value := range matcher.definition.Headers {
+		if !arrays.Contains(ex.request.Header.Values(header), value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (matcher *requestMatcher) matchesQueryParams(ex *exchange) bool {
+	queryParams := ex.request.URL.Query()
+
+	for param, values := range matcher.definition.QueryParams {
+		for _, value := range values {
+			if !arrays.Contains(queryParams[param], value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (matcher *requestMatcher) matchesBody(ex *exchange) bool {
+	if clarumstrings.IsNotBlank(matcher.definition.BodyContains) &&
+		!bytes.Contains(ex.body, []byte(matcher.definition.BodyContains)) {
+		return false
+	}
+
+	return matcher.bodyPattern == nil || matcher.bodyPattern.Match(ex.body)
+}
+
+func (matcher *requestMatcher) String() string {
+	if matcher == nil {
+		return "[any request]"
+	}
+
+	return fmt.Sprintf("%+v", *matcher.definition)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNilMatcherMatchesEverything(t *testing.T) {
+	matcher, err := newRequestMatcher(nil)
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+
+	if !matcher.matches(testExchange("DELETE", "/anything", "")) {
+		t.Errorf("expected nil matcher to match")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	matcher, err := newRequestMatcher(&commands.RequestMatcher{
+		Method:       "post",
+		PathPattern:  "/orders/[0-9]+",
+		Headers:      map[string]string{"x-tenant": "a"},
+		QueryParams:  map[string][]string{"dryRun": {"true"}},
+		BodyContains: "pizza",
+		BodyPattern:  `"amount":\s*[0-9]+`,
+	})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+
+	matching := testExchange("POST", "/orders/12?dryRun=true", `{"item": "pizza", "amount": 2}`)
+	matching.request.Header.Set("X-Tenant", "a")
+	if !matcher.matches(matching) {
+		t.Errorf("expected request to match")
+	}
+
+	mismatches := map[string]*exchange{
+		"method":      testExchange("PUT", "/orders/12?dryRun=true", `{"item": "pizza", "amount": 2}`),
+		"path":        testExchange("POST", "/orders/12/items?dryRun=true", `{"item": "pizza", "amount": 2}`),
+		"queryParams": testExchange("POST", "/orders/12", `{"item": "pizza", "amount": 2}`),
+		"bodyText":    testExchange("POST", "/orders/12?dryRun=true", `{"item": "pasta", "amount": 2}`),
+		"bodyPattern": testExchange("POST", "/orders/12?dryRun=true", `{"item": "pizza"}`),
+		"headers":     testExchange("POST", "/orders/12?dryRun=true", `{"item": "pizza", "amount": 2}`),
+	}
+	for name, ex := range mismatches {
+		if name != "headers" {
+			ex.request.Header.Set("X-Tenant", "a")
+		}
+		if matcher.matches(ex) {
+			t.Errorf("expected %s mismatch", name)
+		}
+	}
+}
+
+func TestMatcherPath(t *testing.T) {
+	matcher, _ := newRequestMatcher(&commands.RequestMatcher{Path: "orders/"})
+
+	if !matcher.matches(testExchange("GET", "/orders", "")) {
+		t.Errorf("expected cleaned path to match")
+	}
+	if matcher.matches(testExchange("GET", "/orders/1", "")) {
+		t.Errorf("expected path mismatch")
+	}
+}
+
+func TestMatcherPathTemplate(t *testing.T) {
+	matcher, _ := newRequestMatcher(&commands.RequestMatcher{Path: "/orders/{id}/items/*"})
+
+	if !matcher.matches(testExchange("GET", "/orders/42/items/7", "")) {
+		t.Errorf("expected path template to match")
+	}
+	if matcher.matches(testExchange("GET", "/orders/42/items", "")) {
+		t.Errorf("expected path template mismatch")
+	}
+
+	matcher, _ = newRequestMatcher(&commands.RequestMatcher{Path: "/files/**"})
+	if !matcher.matches(testExchange("GET", "/files/a/b.txt", "")) {
+		t.Errorf("expected wildcard path to match")
+	}
+}
+
+func TestInvalidMatcher(t *testing.T) {
+	if _, err := newRequestMatcher(&commands.RequestMatcher{PathPattern: "("}); err == nil {
+		t.Errorf("error expected for invalid path pattern")
+	}
+	if _, err := newRequestMatcher(&commands.RequestMatcher{Path: "/orders/@regex(()@"}); err == nil {
+		t.Errorf("error expected for invalid path template")
+	}
+	if _, err := newRequestMatcher(&commands.RequestMatcher{BodyPattern: "("}); err == nil {
+		t.Errorf("error expected for invalid body pattern")
+	}
+}
+
+func testExchange(method string, target string, body string) *exchange {
+	capture := utils.NewCapture(utils.MaxCapturedPayloadSize)
+	capture.Add([]byte(body))
+	return newExchange(httptest.NewRequest(method, target, strings.NewReader(body)), capture)
+}
@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendResponse sends the response according to its behaviour: delayed, in chunks or replaced by a fault
+func sendResponse(logger *logging.Logger, response *commands.SendCommand, request *http.Request, resWriter http.ResponseWriter) {
+	behaviour := response.Behaviour
+	if behaviour == nil {
+		behaviour = &commands.ResponseBehaviour{}
+	}
+
+	if delay := responseDelay(behaviour); delay > 0 {
+		logger.Debugf("delaying response by %s", delay)
+		if !wait(delay, request) {
+			logger.Warn("response canceled during delay - request context is done")
+			return
+		}
+	}
+
+	body, size, err := responseBody(response)
+	if err != nil {
+		sendDefaultErrorResponse(logger, fmt.Sprintf("unable to send response - %s", err), resWriter)
+		return
+	}
+	defer body.Close()
+
+	if behaviour.Fault != commands.NoFault {
+		injectFault(logger, behaviour.Fault, response, resWriter)
+		return
+	}
+
+	for header, value := range response.Headers {
+		resWriter.Header().Set(header, value)
+	}
+	if behaviour.ChunkSize == 0 && response.Binary != nil {
+		resWriter.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	resWriter.WriteHeader(response.StatusCode)
+
+	if behaviour.ChunkSize > 0 {
+		err = writeChunks(body, behaviour, request, resWriter)
+	} else {
+		_, err = io.Copy(resWriter, body)
+	}
+	if err != nil {
+		logger.Errorf("could not write response body - %s", err)
+	}
+	logOutgoingResponse(logger, response.StatusCode, model.DescribePayload(response.Payload, response.Binary), resWriter)
+}
+
+// binary payloads from files are streamed, so that they are never completely loaded into memory
+func responseBody(response *commands.SendCommand) (io.ReadCloser, int64, error) {
+	if response.Binary != nil {
+		return response.Binary.Open()
+	}
+
+	return io.NopCloser(strings.NewReader(response.Payload)), int64(len(response.Payload)), nil
+}
+
+func validateBehaviour(behaviour *commands.ResponseBehaviour) error {
+	if behaviour == nil {
+		return nil
+	}
+	if behaviour.MaxDelay > 0 && behaviour.MaxDelay < behaviour.Delay {
+		return errors.New(fmt.Sprintf("max delay [%s] is smaller than delay [%s]", behaviour.MaxDelay, behaviour.Delay))
+	}
+	if behaviour.ChunkSize < 0 {
+		return errors.New(fmt.Sprintf("unsupported chunk size [%d]", behaviour.ChunkSize))
+	}
+
+	return nil
+}
+
+func responseDelay(behaviour *commands.ResponseBehaviour) time.Duration {
+	if behaviour.MaxDelay > behaviour.Delay {
+		return behaviour.Delay + rand.N(behaviour.MaxDelay-behaviour.Delay)
+	}
+
+	return behaviour.Delay
+}
+
+// writeChunks flushes every chunk, so that the client receives the payload slowly
+func writeChunks(body io.Reader, behaviour *commands.ResponseBehaviour, request *http.Request, resWriter http.ResponseWriter) error {
+	controller := http.NewResponseController(resWriter)
+	chunk := make([]byte, behaviour.ChunkSize)
+
+	for first := true; ; first = false {
+		n, err := io.ReadFull(body, chunk)
+		if n == 0 {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if !first && !wait(behaviour.ChunkDelay, request) {
+			return errors.New("request context is done")
+		}
+		if _, err := resWriter.Write(chunk[:n]); err != nil {
+			return err
+		}
+		if err := controller.Flush(); err != nil {
+			return err
+		}
+		if err != nil {
+			// the last chunk was shorter
+			return nil
+		}
+	}
+}
+
+// injectFault takes over the connection to break the HTTP exchange. HTTP/2 connections cannot be
+// taken over, so the stream is aborted instead, which the client sees as a reset stream.
+func injectFault(logger *logging.Logger, fault commands.Fault, response *commands.SendCommand, resWriter http.ResponseWriter) {
+	logger.Infof("injecting fault [%s] instead of the response", fault)
+
+	conn, buffer, err := http.NewResponseController(resWriter).Hijack()
+	if err != nil {
+		logger.Warnf("unable to take over the connection, aborting the response instead - %s", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	switch fault {
+	case commands.EmptyResponse:
+		writeStatusAndHeaders(buffer, response, "Content-Length", "0")
+	case commands.MalformedChunk:
+		writeStatusAndHeaders(buffer, response, "Transfer-Encoding", "chunked")
+		_, _ = buffer.WriteString("not a chunk size\r\n")
+	case commands.ConnectionReset:
+		setNoLinger(conn)
+	}
+
+	_ = buffer.Flush()
+	if err := conn.Close(); err != nil {
+		logger.Errorf("unable to close the connection - %s", err)
+	}
+}
+
+// the response is written directly to the connection, since the server no longer handles it;
+// the given header replaces the one of the response
+func writeStatusAndHeaders(buffer *bufio.ReadWriter, response *commands.SendCommand, header string, value string) {
+	_, _ = fmt.Fprintf(buffer, "HTTP/1.1 %d %s\r\n", response.StatusCode, http.StatusText(response.StatusCode))
+	for name, headerValue := range response.Headers {
+		if strings.EqualFold(name, header) {
+			continue
+		}
+		_, _ = fmt.Fprintf(buffer, "%s: %s\r\n", name, headerValue)
+	}
+	_, _ = fmt.Fprintf(buffer, "%s: %s\r\n\r\n", header, value)
+}
+
+// closing a connection without lingering sends a TCP reset instead of the normal close
+func setNoLinger(conn net.Conn) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+}
+
+// wait returns false if the request was canceled in the meantime
+func wait(duration time.Duration, request *http.Request) bool {
+	select {
+	case <-time.After(duration):
+		return true
+	case <-request.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/model"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseDelay(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "slow", StatusCode: 200, Payload: "done",
+		Behaviour: &commands.ResponseBehaviour{Delay: 200 * time.Millisecond, MaxDelay: 300 * time.Millisecond}})
+
+	start := time.Now()
+	status, body := testRequest(t, endpoint, "GET", "/slow")
+	elapsed := time.Since(start)
+
+	if status != 200 || body != "done" {
+		t.Errorf("unexpected response %d %s", status, body)
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("expected response to be delayed, but it took %s", elapsed)
+	}
+}
+
+func TestChunkedResponse(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "chunks", StatusCode: 200, Payload: "0123456789",
+		Behaviour: &commands.ResponseBehaviour{ChunkSize: 4, ChunkDelay: 50 * time.Millisecond}})
+
+	start := time.Now()
+	status, body := testRequest(t, endpoint, "GET", "/chunks")
+	elapsed := time.Since(start)
+
+	if status != 200 || body != "0123456789" {
+		t.Errorf("unexpected response %d %s", status, body)
+	}
+	// 3 chunks, 2 delays between them
+	if elapsed < 100*time.Millisecond {
+		t.Errorf("expected chunks to be delayed, but the response took %s", elapsed)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	faults := map[commands.Fault]string{
+		commands.CloseBeforeHeaders: "EOF",
+		commands.MalformedChunk:     "invalid byte in chunk length",
+		commands.ConnectionReset:    "connection reset by peer",
+	}
+
+	for fault, expectedError := range faults {
+		t.Run(fault.String(), func(t *testing.T) {
+			endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+			addStub(t, endpoint, &commands.AddStubCommand{Name: "fault", StatusCode: 200, Payload: "payload",
+				Behaviour: &commands.ResponseBehaviour{Fault: fault}})
+
+			err := faultRequest(endpoint)
+			if err == nil || !strings.Contains(err.Error(), expectedError) {
+				t.Errorf("expected error [%s], but got [%s]", expectedError, err)
+			}
+
+			entries, _ := endpoint.Journal(&commands.GetJournalCommand{})
+			if len(entries) != 1 || entries[0].Stub != "fault" || entries[0].StatusCode != 0 {
+				t.Errorf("unexpected journal entries %+v", entries)
+			}
+		})
+	}
+}
+
+func TestEmptyResponseFault(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "fault", StatusCode: 200, Payload: "payload",
+		Headers:   map[string]string{"Content-Length": "7"},
+		Behaviour: &commands.ResponseBehaviour{Fault: commands.EmptyResponse}})
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	response, err := client.Get(fmt.Sprintf("http://localhost:%d/fault", endpoint.port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	// the client sees a complete response without a body, not a truncated one
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Errorf("expected no error, but got [%s]", err)
+	}
+	if response.StatusCode != 200 || response.ContentLength != 0 || len(body) != 0 {
+		t.Errorf("expected empty response, but got %d with %d bytes [%s]", response.StatusCode, response.ContentLength, body)
+	}
+}
+
+func TestInvalidBehaviour(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+
+	err := endpoint.AddStub(&commands.AddStubCommand{Name: "invalid", StatusCode: 200,
+		Behaviour: &commands.ResponseBehaviour{Delay: time.Second, MaxDelay: time.Millisecond}})
+	if err == nil {
+		t.Errorf("expected error for max delay smaller than delay")
+	}
+}
+
+// faultRequest returns the error of the request, including the ones while reading the body
+func faultRequest(endpoint *Endpoint) error {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	for {
+		response, err := client.Get(fmt.Sprintf("http://localhost:%d/fault", endpoint.port))
+		if err != nil {
+			// the server is started in the background
+			if strings.Contains(err.Error(), "connection refused") {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		_, err = io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		return err
+	}
+}
+
+func TestBinaryFileResponse(t *testing.T) {
+	content := bytes.Repeat([]byte{0x89, 0x50, 0x4e, 0x47, 0x00, 0xff}, 50_000)
+	file := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "image", StatusCode: 200,
+		Binary: &model.BinaryPayload{File: file}})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "chunked", StatusCode: 200,
+		Matcher:   &commands.RequestMatcher{Path: "/chunked"},
+		Binary:    &model.BinaryPayload{File: file},
+		Behaviour: &commands.ResponseBehaviour{ChunkSize: 100_000}})
+
+	for _, path := range []string{"/image", "/chunked"} {
+		status, body := testRequest(t, endpoint, "GET", path)
+		if status != 200 || !bytes.Equal([]byte(body), content) {
+			t.Errorf("unexpected response for %s - status %d with %d bytes", path, status, len(body))
+		}
+	}
+}
+
+func TestInvalidBinaryPayload(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+
+	err := endpoint.AddStub(&commands.AddStubCommand{Name: "missing", StatusCode: 200,
+		Binary: &model.BinaryPayload{File: "missing.pdf"}})
+	if err == nil || !strings.Contains(err.Error(), "payload file is not readable") {
+		t.Errorf("expected error for missing file, but got %s", err)
+	}
+
+	err = endpoint.AddStub(&commands.AddStubCommand{Name: "both", StatusCode: 200, Payload: "text",
+		Binary: &model.BinaryPayload{Bytes: []byte{0x01}}})
+	if err == nil {
+		t.Errorf("expected error for payload & binary payload")
+	}
+}
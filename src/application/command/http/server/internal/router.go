@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// exchange is a request received by the server, together with the channel its response is sent to
+type exchange struct {
+	request *http.Request
+	// body is the beginning of the request body, the request still contains all of it
+	body          []byte
+	bodyTruncated bool
+	// closed once a receive action took the request
+	claimed  chan struct{}
+	response chan *sendPair
+}
+
+// pendingReceive is a receive action waiting for a matching request
+type pendingReceive struct {
+	matcher  *requestMatcher
+	exchange chan *exchange
+}
+
+// router pairs received requests with the receive actions that match them,
+// and received requests with the send actions that answer them.
+// Requests that no receive action is waiting for are answered by a matching stub, if there is one.
+// Requests and actions are always paired in the order they arrived.
+type router struct {
+	lock           sync.Mutex
+	queueUnmatched bool
+	// receive actions waiting for a request
+	pending []*pendingReceive
+	// the most recently added stub comes last
+	stubs []*stub
+	// requests waiting for a receive action
+	queued []*exchange
+	// requests taken by a receive action, waiting for a send action
+	received []*exchange
+	// closed & replaced every time a request is added to received
+	receivedChanged chan struct{}
+}
+
+func newRouter(queueUnmatched bool) *router {
+	return &router{
+		queueUnmatched:  queueUnmatched,
+		receivedChanged: make(chan struct{}),
+	}
+}
+
+func newExchange(request *http.Request, body *utils.Capture) *exchange {
+	return &exchange{
+		request:       request,
+		body:          body.Bytes(),
+		bodyTruncated: body.Truncated(),
+		claimed:       make(chan struct{}),
+		response:      make(chan *sendPair, 1),
+	}
+}
+
+func (ex *exchange) wasClaimed() bool {
+	select {
+	case <-ex.claimed:
+		return true
+	default:
+		return false
+	}
+}
+
+// route hands the request to the oldest pending receive action that matches it, or to the matching stub
+// added last, or queues it. Returns the stub that must answer the request, and false if the request
+// matches neither and unmatched requests are not queued.
+func (r *router) route(ex *exchange) (*stub, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, pending := range r.pending {
+		if pending.matcher.matches(ex) {
+			r.pending = slices.Delete(r.pending, i, i+1)
+			r.claim(ex)
+			pending.exchange <- ex
+			return nil, true
+		}
+	}
+
+	for i := len(r.stubs) - 1; i >= 0; i-- {
+		if r.stubs[i].matcher.matches(ex) {
+			return r.stubs[i], true
+		}
+	}
+
+	if !r.queueUnmatched {
+		return nil, false
+	}
+
+	r.queued = append(r.queued, ex)
+	return nil, true
+}
+
+// addStub registers the stub, replacing the one with the same name. Returns true if a stub was replaced.
+func (r *router) addStub(newStub *stub) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	replaced := r.deleteStub(newStub.name)
+	r.stubs = append(r.stubs, newStub)
+
+	return replaced
+}
+
+// removeStub returns false if there is no stub with the given name
+func (r *router) removeStub(name string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.deleteStub(name)
+}
+
+// must be called while holding the lock
+func (r *router) deleteStub(name string) bool {
+	length := len(r.stubs)
+	r.stubs = slices.DeleteFunc(r.stubs, func(s *stub) bool { return s.name == name })
+
+	return len(r.stubs) != length
+}
+
+// receive takes the oldest queued request that matches. If there is none,
+// the returned pendingReceive is notified once a matching request arrives.
+func (r *router) receive(matcher *requestMatcher) (*exchange, *pendingReceive) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, ex := range r.queued {
+		if matcher.matches(ex) {
+			r.queued = slices.Delete(r.queued, i, i+1)
+			r.claim(ex)
+			return ex, nil
+		}
+	}
+
+	pending := &pendingReceive{
+		matcher:  matcher,
+		exchange: make(chan *exchange, 1),
+	}
+	r.pending = append(r.pending, pending)
+
+	return nil, pending
+}
+
+// cancelReceive removes the pending receive action. If a request was routed to it in the meantime,
+// that request is returned, so that it is not lost.
+func (r *router) cancelReceive(pending *pendingReceive) *exchange {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if i := slices.Index(r.pending, pending); i >= 0 {
+		r.pending = slices.Delete(r.pending, i, i+1)
+		return nil
+	}
+
+	return <-pending.exchange
+}
+
+// respond takes the oldest received request that matches. If there is none, the returned channel
+// is closed as soon as another request was received, so that the caller can try again.
+func (r *router) respond(matcher *requestMatcher) (*exchange, <-chan struct{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for i, ex := range r.received {
+		if matcher.matches(ex) {
+			r.received = slices.Delete(r.received, i, i+1)
+			return ex, nil
+		}
+	}
+
+	return nil, r.receivedChanged
+}
+
+// remove a request that is no longer handled by the server
+func (r *router) remove(ex *exchange) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.queued = slices.DeleteFunc(r.queued, func(e *exchange) bool { return e == ex })
+	r.received = slices.DeleteFunc(r.received, func(e *exchange) bool { return e == ex })
+}
+
+// must be called while holding the lock
+func (r *router) claim(ex *exchange) {
+	close(ex.claimed)
+	r.received = append(r.received, ex)
+
+	close(r.receivedChanged)
+	r.receivedChanged = make(chan struct{})
+}
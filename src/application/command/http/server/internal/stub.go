@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+)
+
+// stub is a canned response, sent for every request that matches it
+type stub struct {
+	name     string
+	matcher  *requestMatcher
+	response *commands.SendCommand
+}
+
+func newStub(action *commands.AddStubCommand, matcher *requestMatcher) *stub {
+	return &stub{
+		name:    action.Name,
+		matcher: matcher,
+		response: &commands.SendCommand{
+			Name:         action.Name,
+			StatusCode:   action.StatusCode,
+			Headers:      action.Headers,
+			Payload:      action.Payload,
+			EndpointName: action.EndpointName,
+			Behaviour:    action.Behaviour,
+			Binary:       action.Binary,
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"crypto/tls"
+	"errors"
+	"github.com/go-clarum/agent/application/command/http/common/certificates"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+)
+
+// newTlsConfig returns nil if the server does not use TLS. The PEM encoded certificate
+// of the test CA is returned as well, if any certificate of the server is related to it.
+func newTlsConfig(config *commands.TlsConfig) (*tls.Config, string, error) {
+	if config == nil {
+		return nil, "", nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	caCertificate := ""
+
+	switch {
+	case config.GenerateCertificate:
+		ca, err := certificates.TestCA()
+		if err != nil {
+			return nil, "", err
+		}
+		certificate, err := ca.IssueServerCertificate(config.Hosts)
+		if err != nil {
+			return nil, "", err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+		caCertificate = ca.PEM()
+	case clarumstrings.IsNotBlank(config.CertFile) && clarumstrings.IsNotBlank(config.KeyFile):
+		certificate, err := certificates.LoadKeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, "", err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	default:
+		return nil, "", errors.New("either a certificate & key file or a generated certificate is required")
+	}
+
+	if config.RequireClientCertificate {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+		if clarumstrings.IsNotBlank(config.ClientCaFile) {
+			pool, err := certificates.LoadCertPool(config.ClientCaFile)
+			if err != nil {
+				return nil, "", err
+			}
+			tlsConfig.ClientCAs = pool
+		} else {
+			ca, err := certificates.TestCA()
+			if err != nil {
+				return nil, "", err
+			}
+			tlsConfig.ClientCAs = ca.CertPool()
+			caCertificate = ca.PEM()
+		}
+	}
+
+	if clarumstrings.IsNotBlank(config.ExportCaFile) {
+		ca, err := certificates.TestCA()
+		if err != nil {
+			return nil, "", err
+		}
+		if err := ca.Export(config.ExportCaFile); err != nil {
+			return nil, "", err
+		}
+		caCertificate = ca.PEM()
+	}
+
+	return tlsConfig, caCertificate, nil
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/common/certificates"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratedCertificate(t *testing.T) {
+	exportFile := filepath.Join(t.TempDir(), "ca.pem")
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{
+		Name: "server",
+		Tls:  &commands.TlsConfig{GenerateCertificate: true, ExportCaFile: exportFile},
+	})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: 200, Payload: "secure"})
+
+	exported, err := os.ReadFile(exportFile)
+	if err != nil || string(exported) != endpoint.CaCertificate() {
+		t.Fatalf("expected the CA certificate to be exported - %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM([]byte(endpoint.CaCertificate()))
+	client := tlsClient(&tls.Config{RootCAs: pool})
+
+	if status, body, err := tlsRequest(client, endpoint); err != nil || status != 200 || body != "secure" {
+		t.Errorf("unexpected HTTPS response %d %s - %s", status, body, err)
+	}
+
+	if _, _, err := tlsRequest(tlsClient(&tls.Config{}), endpoint); err == nil {
+		t.Errorf("expected the certificate to be untrusted without the test CA")
+	}
+}
+
+func TestMutualTls(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{
+		Name: "server",
+		Tls:  &commands.TlsConfig{GenerateCertificate: true, RequireClientCertificate: true},
+	})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: 200})
+
+	ca, _ := certificates.TestCA()
+	clientCertificate, err := ca.IssueClientCertificate("test-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withCertificate := tlsClient(&tls.Config{RootCAs: ca.CertPool(), Certificates: []tls.Certificate{clientCertificate}})
+	if status, _, err := tlsRequest(withCertificate, endpoint); err != nil || status != 200 {
+		t.Errorf("expected request with client certificate to succeed, got %d - %s", status, err)
+	}
+
+	withoutCertificate := tlsClient(&tls.Config{RootCAs: ca.CertPool()})
+	if _, _, err := tlsRequest(withoutCertificate, endpoint); err == nil {
+		t.Errorf("expected request without client certificate to fail")
+	}
+}
+
+func TestInvalidTlsConfig(t *testing.T) {
+	if _, err := NewEndpoint(&commands.InitEndpointCommand{Name: "server", Tls: &commands.TlsConfig{}}); err == nil {
+		t.Errorf("error expected for TLS config without certificate")
+	}
+
+	_, err := NewEndpoint(&commands.InitEndpointCommand{
+		Name: "server",
+		Tls:  &commands.TlsConfig{CertFile: "missing.pem", KeyFile: "missing-key.pem"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unable to load certificate") {
+		t.Errorf("expected certificate loading error, but got %s", err)
+	}
+}
+
+func tlsClient(config *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: config},
+	}
+}
+
+func tlsRequest(client *http.Client, endpoint *Endpoint) (int, string, error) {
+	url := fmt.Sprintf("https://localhost:%d/", endpoint.port)
+
+	for {
+		response, err := client.Get(url)
+		if err != nil {
+			// the server is started in the background
+			if strings.Contains(err.Error(), "connection refused") {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return 0, "", err
+		}
+
+		body, _ := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		return response.StatusCode, string(body), nil
+	}
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"strings"
+	"time"
+)
+
+const verificationPollInterval = 100 * time.Millisecond
+
+// Journal returns the journal entries of the requests matching the action, oldest first
+func (endpoint *Endpoint) Journal(action *commands.GetJournalCommand) ([]*commands.JournalEntry, error) {
+	matcher, err := newRequestMatcher(action.Matcher)
+	if err != nil {
+		return nil, endpoint.handleError("get journal action is invalid", err)
+	}
+
+	return endpoint.journal.entries(matcher), nil
+}
+
+// VerifyRequestCount validates the number of journal entries matching the action.
+// Since the journal only grows, too many requests fail the verification immediately.
+func (endpoint *Endpoint) VerifyRequestCount(action *commands.VerifyRequestCountCommand) (int, error) {
+	matcher, err := newRequestMatcher(action.Matcher)
+	if err != nil {
+		return 0, endpoint.handleError("verify request count action is invalid", err)
+	}
+
+	deadline := time.Now().Add(action.Timeout)
+	for {
+		count := len(endpoint.journal.entries(matcher))
+
+		if count == action.Count {
+			endpoint.logger.Infof("request count validation successful - %d requests matching %s", count, matcher)
+			return count, nil
+		}
+		if count > action.Count || time.Now().After(deadline) {
+			return count, endpoint.handleError(fmt.Sprintf("validation error - request count mismatch - expected [%d] but received [%d] requests matching %s",
+				action.Count, count, matcher), nil)
+		}
+
+		select {
+		case <-time.After(verificationPollInterval):
+		case <-endpoint.context.Done():
+			return count, endpoint.handleError("verify request count action canceled - endpoint was shut down", nil)
+		}
+	}
+}
+
+// VerifyRequestOrder validates that the journal contains requests matching the matchers of the action in their order
+func (endpoint *Endpoint) VerifyRequestOrder(action *commands.VerifyRequestOrderCommand) error {
+	matchers := make([]*requestMatcher, len(action.Matchers))
+	for i, definition := range action.Matchers {
+		matcher, err := newRequestMatcher(definition)
+		if err != nil {
+			return endpoint.handleError("verify request order action is invalid", err)
+		}
+		matchers[i] = matcher
+	}
+
+	next := 0
+	for _, record := range endpoint.journal.records() {
+		if next < len(matchers) && matchers[next].matches(record.exchange) {
+			next++
+		}
+	}
+
+	if next < len(matchers) {
+		return endpoint.handleError(fmt.Sprintf("validation error - request order mismatch - no request matching %s received after the previous ones",
+			matchers[next]), nil)
+	}
+
+	endpoint.logger.Info("request order validation successful")
+	return nil
+}
+
+// VerifyNoUnexpectedRequests fails for completed requests that were neither received by an action nor stubbed.
+// Requests still waiting for a receive action are not considered unexpected yet.
+func (endpoint *Endpoint) VerifyNoUnexpectedRequests() error {
+	var unexpected []string
+	for _, entry := range endpoint.journal.entries(nil) {
+		if entry.Completed && !entry.Received && entry.Stub == "" {
+			unexpected = append(unexpected, fmt.Sprintf("%s %s", entry.Method, entry.Url))
+		}
+	}
+
+	if len(unexpected) > 0 {
+		return endpoint.handleError(fmt.Sprintf("validation error - received %d unexpected requests [%s]",
+			len(unexpected), strings.Join(unexpected, ", ")), nil)
+	}
+
+	endpoint.logger.Info("no unexpected requests validation successful")
+	return nil
+}
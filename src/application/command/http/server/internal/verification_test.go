@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequestCount(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "orders", StatusCode: 201})
+	orders := &commands.RequestMatcher{Method: "POST", Path: "/orders"}
+
+	// the requests are sent asynchronously by the system under test
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(50 * time.Millisecond)
+			testRequest(t, endpoint, "POST", "/orders")
+		}
+	}()
+
+	count, err := endpoint.VerifyRequestCount(&commands.VerifyRequestCountCommand{Matcher: orders, Count: 3, Timeout: 2 * time.Second})
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 requests, but got %d - %s", count, err)
+	}
+
+	count, err = endpoint.VerifyRequestCount(&commands.VerifyRequestCountCommand{Matcher: orders, Count: 2, Timeout: 2 * time.Second})
+	if err == nil || count != 3 {
+		t.Errorf("expected too many requests to fail immediately, but got %d - %s", count, err)
+	}
+
+	count, err = endpoint.VerifyRequestCount(&commands.VerifyRequestCountCommand{Matcher: &commands.RequestMatcher{Method: "DELETE"}})
+	if err != nil || count != 0 {
+		t.Errorf("expected no requests, but got %d - %s", count, err)
+	}
+}
+
+func TestVerifyRequestOrder(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server"})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: 200})
+
+	for _, path := range []string{"/token", "/health", "/orders", "/payments"} {
+		testRequest(t, endpoint, "GET", path)
+	}
+
+	inOrder := &commands.VerifyRequestOrderCommand{Matchers: []*commands.RequestMatcher{
+		{Path: "/token"}, {Path: "/orders"}, {Path: "/payments"},
+	}}
+	if err := endpoint.VerifyRequestOrder(inOrder); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+
+	outOfOrder := &commands.VerifyRequestOrderCommand{Matchers: []*commands.RequestMatcher{
+		{Path: "/payments"}, {Path: "/orders"},
+	}}
+	if err := endpoint.VerifyRequestOrder(outOfOrder); err == nil {
+		t.Errorf("error expected for requests out of order")
+	}
+}
+
+func TestVerifyNoUnexpectedRequests(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server", UnmatchedRequests: commands.Reject})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "health", Matcher: &commands.RequestMatcher{Path: "/health"}, StatusCode: 200})
+
+	testRequest(t, endpoint, "GET", "/health")
+	if err := endpoint.VerifyNoUnexpectedRequests(); err != nil {
+		t.Errorf("no error expected for stubbed requests, but got %s", err)
+	}
+
+	testRequest(t, endpoint, "DELETE", "/orders/1")
+	if err := endpoint.VerifyNoUnexpectedRequests(); err == nil {
+		t.Errorf("error expected for rejected request")
+	}
+}
+
+func TestJournal(t *testing.T) {
+	endpoint := startTestEndpoint(t, &commands.InitEndpointCommand{Name: "server", JournalSize: 2})
+	addStub(t, endpoint, &commands.AddStubCommand{Name: "any", StatusCode: http.StatusAccepted, Payload: "ok"})
+
+	for _, path := range []string{"/one", "/two", "/three"} {
+		testRequest(t, endpoint, "GET", path)
+	}
+
+	entries, err := endpoint.Journal(&commands.GetJournalCommand{})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	if len(entries) != 2 || entries[0].Url != "/two" || entries[1].Url != "/three" {
+		t.Fatalf("expected the journal to keep the last 2 requests, got %+v", entries)
+	}
+	if !entries[1].Completed || entries[1].StatusCode != http.StatusAccepted || entries[1].ResponsePayload != "ok" {
+		t.Errorf("unexpected journal entry %+v", entries[1])
+	}
+
+	entries, _ = endpoint.Journal(&commands.GetJournalCommand{Matcher: &commands.RequestMatcher{Path: "/three"}})
+	if len(entries) != 1 {
+		t.Errorf("expected 1 matching journal entry, got %d", len(entries))
+	}
+}
@@ -1,65 +1,217 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"github.com/go-clarum/agent/application/command/common"
+	"github.com/go-clarum/agent/application/command/http/common/utils"
+	"github.com/go-clarum/agent/application/command/http/common/validators"
 	"github.com/go-clarum/agent/application/command/http/server/commands"
 	"github.com/go-clarum/agent/application/command/http/server/internal"
+	"github.com/go-clarum/agent/application/session"
 	"github.com/go-clarum/agent/infrastructure/logging"
-	"net/http"
 )
 
 type handler struct {
-	endpoints map[string]*internal.Endpoint
+	endpoints *common.EndpointRegistry[*internal.Endpoint]
 	logger    *logging.Logger
 }
 
 func NewHttpServerHandler() common.CommandHandler {
 	return &handler{
-		endpoints: make(map[string]*internal.Endpoint),
+		endpoints: common.NewEndpointRegistry[*internal.Endpoint](),
 		logger:    logging.NewLogger("HttpServerService"),
 	}
 }
 
-func (h *handler) CanHandle(command any) bool {
-	return true
+func (h *handler) HandledCommands() []any {
+	return []any{
+		&commands.InitEndpointCommand{},
+		&commands.SendCommand{},
+		&commands.ReceiveCommand{},
+		&commands.AddStubCommand{},
+		&commands.RemoveStubCommand{},
+		&commands.GetJournalCommand{},
+		&commands.VerifyRequestCountCommand{},
+		&commands.VerifyRequestOrderCommand{},
+		&commands.VerifyNoUnexpectedRequestsCommand{},
+	}
+}
+
+func (h *handler) Handle(session *session.Session, command any) any {
+	switch c := command.(type) {
+	case *commands.InitEndpointCommand:
+		caCertificate, err := h.InitializeEndpoint(session.Id, c)
+		return &commands.InitEndpointResult{CaCertificate: caCertificate, Error: err}
+	case *commands.SendCommand:
+		return &commands.SendResult{Error: h.SendAction(session.Id, session.Variables, c)}
+	case *commands.ReceiveCommand:
+		request, variables, err := h.ReceiveAction(session.Id, session.Variables, c)
+		return &commands.ReceiveResult{Request: request, Variables: variables, Error: err}
+	case *commands.AddStubCommand:
+		return &commands.AddStubResult{Error: h.AddStub(session.Id, c)}
+	case *commands.RemoveStubCommand:
+		return &commands.RemoveStubResult{Error: h.RemoveStub(session.Id, c)}
+	case *commands.GetJournalCommand:
+		entries, err := h.GetJournal(session.Id, c)
+		return &commands.GetJournalResult{Entries: entries, Error: err}
+	case *commands.VerifyRequestCountCommand:
+		count, err := h.VerifyRequestCount(session.Id, c)
+		return &commands.VerifyRequestCountResult{Count: count, Error: err}
+	case *commands.VerifyRequestOrderCommand:
+		return &commands.VerifyRequestOrderResult{Error: h.VerifyRequestOrder(session.Id, c)}
+	case *commands.VerifyNoUnexpectedRequestsCommand:
+		return &commands.VerifyNoUnexpectedRequestsResult{Error: h.VerifyNoUnexpectedRequests(session.Id, c)}
+	default:
+		h.logger.Errorf("unsupported command [%T]", command)
+		return nil
+	}
 }
 
-func (h *handler) Handle(command any) any {
-	return nil
+func (h *handler) CloseSession(sessionId string) {
+	for _, endpoint := range h.endpoints.RemoveSession(sessionId) {
+		h.logger.Infof("shutting down HTTP server endpoint [%s]", endpoint.Name)
+		endpoint.Shutdown()
+	}
 }
 
-func (h *handler) InitializeEndpoint(is *commands.InitEndpointCommand) error {
-	newEndpoint := internal.NewEndpoint(is)
+// InitializeEndpoint returns the PEM encoded certificate of the test CA, if the endpoint uses it
+func (h *handler) InitializeEndpoint(sessionId string, is *commands.InitEndpointCommand) (string, error) {
+	newEndpoint, err := internal.NewEndpoint(is)
+	if err != nil {
+		return "", err
+	}
 
-	if oldEndpoint, exists := h.endpoints[newEndpoint.Name]; exists {
+	if oldEndpoint, exists := h.endpoints.Remove(sessionId, newEndpoint.Name); exists {
 		h.logger.Infof("endpoint [%s] already exists - replacing", oldEndpoint.Name)
 		oldEndpoint.Shutdown()
 	}
 
-	newEndpoint.Start()
+	if err := newEndpoint.Start(); err != nil {
+		return "", err
+	}
 
-	h.endpoints[newEndpoint.Name] = newEndpoint
+	h.endpoints.Put(sessionId, newEndpoint.Name, newEndpoint)
 	logging.Infof("registered HTTP server endpoint [%s]", newEndpoint.Name)
 
-	return nil
+	return newEndpoint.CaCertificate(), nil
 }
 
-func (h *handler) SendAction(sendAction *commands.SendCommand) error {
-	endpoint, exists := h.endpoints[sendAction.EndpointName]
+func (h *handler) SendAction(sessionId string, variables *session.Variables, sendAction *commands.SendCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, sendAction.EndpointName)
 	if !exists {
-		h.logger.Errorf("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+		return h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
 			sendAction.EndpointName, sendAction.Name)
 	}
 
+	if sendAction.ResolvePlaceholders {
+		if err := utils.ResolvePlaceholders(variables, utils.SendFields{
+			Headers: sendAction.Headers,
+			Payload: &sendAction.Payload,
+		}); err != nil {
+			return h.handleError("action [%s] will not be executed - %s", sendAction.Name, err)
+		}
+	}
+
 	return endpoint.Send(sendAction)
 }
 
-func (h *handler) ReceiveAction(receiveAction *commands.ReceiveCommand) (*http.Request, error) {
-	endpoint, exists := h.endpoints[receiveAction.EndpointName]
+// ReceiveAction saves the values extracted from the received request as session variables
+func (h *handler) ReceiveAction(sessionId string, variables *session.Variables,
+	receiveAction *commands.ReceiveCommand) (*commands.ReceivedRequest, map[string]string, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, receiveAction.EndpointName)
 	if !exists {
-		h.logger.Errorf("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+		return nil, nil, h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
 			receiveAction.EndpointName, receiveAction.Name)
 	}
 
-	return endpoint.Receive(receiveAction)
+	request, err := endpoint.Receive(receiveAction)
+	if request == nil {
+		return nil, nil, err
+	}
+
+	values, extractionErr := validators.ExtractValues(receiveAction.Extractions, receiveAction.Namespaces,
+		request.Headers, request.Path, request.Payload, h.logger)
+	if receiveAction.SavePathVariables && len(request.PathVariables) > 0 {
+		if values == nil {
+			values = make(map[string]string)
+		}
+		// extracted values take precedence over path variables with the same name
+		for name, value := range request.PathVariables {
+			if _, exists := values[name]; !exists {
+				values[name] = value
+			}
+		}
+	}
+	for name, value := range values {
+		variables.Set(name, value)
+	}
+
+	return request, values, errors.Join(err, extractionErr)
+}
+
+func (h *handler) AddStub(sessionId string, addStub *commands.AddStubCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, addStub.EndpointName)
+	if !exists {
+		return h.handleError("HTTP server endpoint [%s] not found - stub [%s] will not be added",
+			addStub.EndpointName, addStub.Name)
+	}
+
+	return endpoint.AddStub(addStub)
+}
+
+func (h *handler) RemoveStub(sessionId string, removeStub *commands.RemoveStubCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, removeStub.EndpointName)
+	if !exists {
+		return h.handleError("HTTP server endpoint [%s] not found - stub [%s] will not be removed",
+			removeStub.EndpointName, removeStub.Name)
+	}
+
+	return endpoint.RemoveStub(removeStub)
+}
+
+func (h *handler) GetJournal(sessionId string, getJournal *commands.GetJournalCommand) ([]*commands.JournalEntry, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, getJournal.EndpointName)
+	if !exists {
+		return nil, h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+			getJournal.EndpointName, getJournal.Name)
+	}
+
+	return endpoint.Journal(getJournal)
+}
+
+func (h *handler) VerifyRequestCount(sessionId string, verifyCount *commands.VerifyRequestCountCommand) (int, error) {
+	endpoint, exists := h.endpoints.Get(sessionId, verifyCount.EndpointName)
+	if !exists {
+		return 0, h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+			verifyCount.EndpointName, verifyCount.Name)
+	}
+
+	return endpoint.VerifyRequestCount(verifyCount)
+}
+
+func (h *handler) VerifyRequestOrder(sessionId string, verifyOrder *commands.VerifyRequestOrderCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, verifyOrder.EndpointName)
+	if !exists {
+		return h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+			verifyOrder.EndpointName, verifyOrder.Name)
+	}
+
+	return endpoint.VerifyRequestOrder(verifyOrder)
+}
+
+func (h *handler) VerifyNoUnexpectedRequests(sessionId string, verify *commands.VerifyNoUnexpectedRequestsCommand) error {
+	endpoint, exists := h.endpoints.Get(sessionId, verify.EndpointName)
+	if !exists {
+		return h.handleError("HTTP server endpoint [%s] not found - action [%s] will not be executed",
+			verify.EndpointName, verify.Name)
+	}
+
+	return endpoint.VerifyNoUnexpectedRequests()
+}
+
+func (h *handler) handleError(format string, a ...any) error {
+	errorMessage := fmt.Sprintf(format, a...)
+	h.logger.Errorf(errorMessage)
+	return errors.New(errorMessage)
 }
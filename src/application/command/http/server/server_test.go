@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"github.com/go-clarum/agent/application/command/http/server/commands"
+	"github.com/go-clarum/agent/application/session"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestTemplateFunctionsAreOnlyResolvedIfEnabled(t *testing.T) {
+	s := session.NewSession("test")
+	h := NewHttpServerHandler().(*handler)
+	t.Cleanup(func() { h.CloseSession(s.Id) })
+	port := freePort(t)
+	if _, err := h.InitializeEndpoint(s.Id, &commands.InitEndpointCommand{Name: "server", Port: port}); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			response, err := http.Get(fmt.Sprintf("http://localhost:%d/", port))
+			if err != nil {
+				responses <- err.Error()
+				continue
+			}
+			body, _ := io.ReadAll(response.Body)
+			_ = response.Body.Close()
+			responses <- string(body)
+		}
+	}()
+
+	for _, action := range []*commands.SendCommand{
+		{EndpointName: "server", StatusCode: 200, Payload: "`${uuid()}`"},
+		{EndpointName: "server", StatusCode: 200, Payload: "${base64(abc)}", ResolvePlaceholders: true},
+	} {
+		if _, _, err := h.ReceiveAction(s.Id, s.Variables, &commands.ReceiveCommand{EndpointName: "server", Method: http.MethodGet}); err != nil {
+			t.Fatalf("no receive error expected, but got %s", err)
+		}
+		if err := h.SendAction(s.Id, s.Variables, action); err != nil {
+			t.Fatalf("no send error expected, but got %s", err)
+		}
+	}
+
+	if response := <-responses; response != "`${uuid()}`" {
+		t.Errorf("expected the payload to be sent verbatim, but got [%s]", response)
+	}
+	if response := <-responses; response != "YWJj" {
+		t.Errorf("expected the template function to be resolved, but got [%s]", response)
+	}
+}
+
+func freePort(t *testing.T) uint {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	return uint(listener.Addr().(*net.TCPAddr).Port)
+}
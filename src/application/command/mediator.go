@@ -1,39 +1,94 @@
 package command
 
 import (
+	"errors"
+	"fmt"
 	"github.com/go-clarum/agent/application/command/common"
-	httpClient "github.com/go-clarum/agent/application/command/http/client"
-	httpServer "github.com/go-clarum/agent/application/command/http/server"
+	"github.com/go-clarum/agent/application/session"
 	"github.com/go-clarum/agent/infrastructure/logging"
+	"reflect"
+	"sync"
 )
 
-var med *mediator
+var med *Mediator
+var medOnce sync.Once
 
-type mediator struct {
+// Mediator routes every command to the single handler registered for its type.
+type Mediator struct {
 	logger   *logging.Logger
+	routes   map[reflect.Type]common.CommandHandler
 	handlers []common.CommandHandler
 }
 
-func GetMediator() *mediator {
-	if med == nil {
-		med = &mediator{
-			logger: logging.NewLogger("commandMediator"),
-		}
-	}
+// GetMediator returns the mediator of the agent, with the handlers of all protocol modules registered.
+func GetMediator() *Mediator {
+	medOnce.Do(func() {
+		med = NewMediator()
 
-	med.handlers = append(med.handlers, httpClient.NewHttpClientHandler())
-	med.handlers = append(med.handlers, httpServer.NewHttpServerHandler())
+		for _, newHandler := range modules {
+			if err := med.Register(newHandler()); err != nil {
+				panic(fmt.Sprintf("unable to register protocol module - %s", err))
+			}
+		}
+	})
 
 	return med
 }
 
-func (m *mediator) DelegateCommand(command any) any {
-	for _, h := range m.handlers {
-		if h.CanHandle(command) {
-			return h.Handle(command)
+func NewMediator() *Mediator {
+	return &Mediator{
+		logger: logging.NewLogger("commandMediator"),
+		routes: make(map[reflect.Type]common.CommandHandler),
+	}
+}
+
+// Register routes all command types declared by the handler to it.
+// A command type can only be handled by one handler.
+func (m *Mediator) Register(handler common.CommandHandler) error {
+	commands := handler.HandledCommands()
+	if len(commands) == 0 {
+		return errors.New(fmt.Sprintf("handler [%T] does not declare any commands", handler))
+	}
+
+	for _, command := range commands {
+		commandType := reflect.TypeOf(command)
+		if existing, exists := m.routes[commandType]; exists {
+			return errors.New(fmt.Sprintf("command [%s] of handler [%T] is already handled by [%T]",
+				commandType, handler, existing))
 		}
 	}
 
-	m.logger.Errorf("unable to handle command: %v", command)
+	for _, command := range commands {
+		m.routes[reflect.TypeOf(command)] = handler
+	}
+	m.handlers = append(m.handlers, handler)
+
 	return nil
 }
+
+func (m *Mediator) DelegateCommand(session *session.Session, command any) (any, error) {
+	handler, exists := m.routes[reflect.TypeOf(command)]
+	if !exists {
+		return nil, m.handleError("no handler registered for command [%T]", command)
+	}
+
+	result := handler.Handle(session, command)
+	if result == nil {
+		return nil, m.handleError("handler [%T] returned no result for command [%T]", handler, command)
+	}
+
+	return result, nil
+}
+
+// CloseSession lets every handler shut down the endpoints created by the given session.
+func (m *Mediator) CloseSession(sessionId string) {
+	for _, h := range m.handlers {
+		h.CloseSession(sessionId)
+	}
+}
+
+func (m *Mediator) handleError(format string, a ...any) error {
+	errorMessage := fmt.Sprintf(format, a...)
+	m.logger.Errorf(errorMessage)
+	return errors.New(errorMessage)
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"github.com/go-clarum/agent/application/session"
+	"testing"
+)
+
+type pingCommand struct{}
+type pongCommand struct{}
+type unknownCommand struct{}
+
+type testHandler struct {
+	commands       []any
+	closedSessions []string
+}
+
+func (h *testHandler) HandledCommands() []any {
+	return h.commands
+}
+
+func (h *testHandler) Handle(_ *session.Session, command any) any {
+	switch command.(type) {
+	case *pingCommand:
+		return "ping handled"
+	default:
+		return nil
+	}
+}
+
+func (h *testHandler) CloseSession(sessionId string) {
+	h.closedSessions = append(h.closedSessions, sessionId)
+}
+
+func TestDelegateCommand(t *testing.T) {
+	mediator := NewMediator()
+	if err := mediator.Register(&testHandler{commands: []any{&pingCommand{}}}); err != nil {
+		t.Fatalf("no registration error expected, but got %s", err)
+	}
+
+	result, err := mediator.DelegateCommand(session.NewSession("test"), &pingCommand{})
+
+	if err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+	if result != "ping handled" {
+		t.Errorf("expected <ping handled> but received %v", result)
+	}
+}
+
+func TestDelegateCommandNoHandler(t *testing.T) {
+	mediator := NewMediator()
+	_ = mediator.Register(&testHandler{commands: []any{&pingCommand{}}})
+
+	_, err := mediator.DelegateCommand(session.NewSession("test"), &unknownCommand{})
+
+	if err == nil {
+		t.Fatalf("error expected, but got none")
+	}
+	if err.Error() != "no handler registered for command [*command.unknownCommand]" {
+		t.Errorf("error message is unexpected: %s", err)
+	}
+}
+
+func TestDelegateCommandNoResult(t *testing.T) {
+	mediator := NewMediator()
+	_ = mediator.Register(&testHandler{commands: []any{&pongCommand{}}})
+
+	_, err := mediator.DelegateCommand(session.NewSession("test"), &pongCommand{})
+
+	if err == nil {
+		t.Fatalf("error expected, but got none")
+	}
+	if err.Error() != "handler [*command.testHandler] returned no result for command [*command.pongCommand]" {
+		t.Errorf("error message is unexpected: %s", err)
+	}
+}
+
+func TestRegisterDuplicateCommand(t *testing.T) {
+	mediator := NewMediator()
+	_ = mediator.Register(&testHandler{commands: []any{&pingCommand{}}})
+
+	err := mediator.Register(&testHandler{commands: []any{&pongCommand{}, &pingCommand{}}})
+
+	if err == nil {
+		t.Fatalf("registration error expected, but got none")
+	}
+
+	// the failed registration must not leave a partial route behind
+	if _, err := mediator.DelegateCommand(session.NewSession("test"), &pongCommand{}); err == nil {
+		t.Errorf("expected no route for <pongCommand>")
+	}
+}
+
+func TestCloseSession(t *testing.T) {
+	mediator := NewMediator()
+	handler1 := &testHandler{commands: []any{&pingCommand{}}}
+	handler2 := &testHandler{commands: []any{&pongCommand{}}}
+	_ = mediator.Register(handler1)
+	_ = mediator.Register(handler2)
+
+	mediator.CloseSession("session1")
+
+	if len(handler1.closedSessions) != 1 || len(handler2.closedSessions) != 1 {
+		t.Errorf("expected session to be closed on all handlers")
+	}
+}
+
+func TestModulesRegistration(t *testing.T) {
+	mediator := GetMediator()
+
+	if len(mediator.handlers) != len(modules) {
+		t.Errorf("expected %d handlers but received %d", len(modules), len(mediator.handlers))
+	}
+	if GetMediator() != mediator {
+		t.Errorf("expected the same mediator instance")
+	}
+}
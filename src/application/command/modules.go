@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd"
+	"github.com/go-clarum/agent/application/command/common"
+	httpClient "github.com/go-clarum/agent/application/command/http/client"
+	httpServer "github.com/go-clarum/agent/application/command/http/server"
+)
+
+// modules are the protocol modules of the agent. A new protocol module plugs in
+// by implementing common.CommandHandler and adding its constructor to this list.
+var modules = []func() common.CommandHandler{
+	cmd.NewCommandHandler,
+	httpClient.NewHttpClientHandler,
+	httpServer.NewHttpServerHandler,
+}
@@ -2,18 +2,21 @@ package agent
 
 import (
 	"github.com/go-clarum/agent/application/services/agent/interfaces"
+	sessionInterfaces "github.com/go-clarum/agent/application/services/session/interfaces"
 	"github.com/go-clarum/agent/infrastructure/config"
 	"github.com/go-clarum/agent/infrastructure/logging"
 	"os"
 )
 
 type service struct {
-	logger *logging.Logger
+	sessionService sessionInterfaces.SessionService
+	logger         *logging.Logger
 }
 
-func NewAgentService() interfaces.AgentService {
+func NewAgentService(sessionService sessionInterfaces.SessionService) interfaces.AgentService {
 	return &service{
-		logger: logging.NewLogger("AgentService"),
+		sessionService: sessionService,
+		logger:         logging.NewLogger("AgentService"),
 	}
 }
 
@@ -23,5 +26,6 @@ func (s service) Status() string {
 
 func (s service) Shutdown() {
 	s.logger.Info("received shutdown signal from binding")
+	s.sessionService.CloseAll()
 	os.Exit(0)
 }
@@ -1,6 +0,0 @@
-package interfaces
-
-type CommandService interface {
-	InitializeEndpoint(name string, cmdComponents []string, warmupMillis int32) error
-	ShutdownEndpoint(name string) error
-}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "github.com/go-clarum/agent/application/session"
+
+type SessionService interface {
+	Open(clientName string) *session.Session
+	Close(sessionId string)
+	CloseAll()
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"github.com/go-clarum/agent/application/command"
+	"github.com/go-clarum/agent/application/services/session/interfaces"
+	"github.com/go-clarum/agent/application/session"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"sync"
+)
+
+type service struct {
+	sessions map[string]*session.Session
+	lock     sync.Mutex
+	mediator *command.Mediator
+	logger   *logging.Logger
+}
+
+func NewSessionService() interfaces.SessionService {
+	return &service{
+		sessions: make(map[string]*session.Session),
+		mediator: command.GetMediator(),
+		logger:   logging.NewLogger("SessionService"),
+	}
+}
+
+func (s *service) Open(clientName string) *session.Session {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	newSession := session.NewSession(clientName)
+	s.sessions[newSession.Id] = newSession
+	s.logger.Infof("opened session [%s] for client [%s]", newSession.Id, clientName)
+
+	return newSession
+}
+
+// Close tears down everything the session has created.
+func (s *service) Close(sessionId string) {
+	s.lock.Lock()
+	closedSession, exists := s.sessions[sessionId]
+	delete(s.sessions, sessionId)
+	s.lock.Unlock()
+
+	if !exists {
+		s.logger.Warnf("session [%s] does not exist - nothing to close", sessionId)
+		return
+	}
+
+	s.logger.Infof("closing session [%s] of client [%s]", closedSession.Id, closedSession.ClientName)
+	s.mediator.CloseSession(sessionId)
+	closedSession.Close()
+}
+
+// CloseAll tears down all open sessions, so that no started process outlives the agent.
+func (s *service) CloseAll() {
+	s.lock.Lock()
+	var sessionIds []string
+	for sessionId := range s.sessions {
+		sessionIds = append(sessionIds, sessionId)
+	}
+	s.lock.Unlock()
+
+	for _, sessionId := range sessionIds {
+		s.Close(sessionId)
+	}
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"github.com/go-clarum/agent/application/utils/uuids"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"sync"
+)
+
+const eventsBufferSize = 1000
+
+// Session groups everything a binding creates over one Session stream.
+// Endpoints are registered per session, so that several test suites can run against
+// the same agent without their endpoint names colliding.
+type Session struct {
+	Id         string
+	ClientName string
+	Variables  *Variables
+	events     chan any
+	lock       sync.RWMutex
+	closed     bool
+}
+
+type InitCommand struct {
+	ClientName string
+}
+
+type InitResult struct {
+	SessionId string
+	Error     error
+}
+
+func NewSession(clientName string) *Session {
+	return &Session{
+		Id:         uuids.New(),
+		ClientName: clientName,
+		Variables:  NewVariables(),
+		events:     make(chan any, eventsBufferSize),
+	}
+}
+
+// Publish sends an event to the binding of this session, outside the request/result flow of commands.
+// Publish never blocks, events are discarded if the binding does not keep up or the session is closed.
+func (s *Session) Publish(event any) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		logging.Warnf("session [%s] event buffer is full - event discarded", s.Id)
+	}
+}
+
+// Events returns the channel of published events, it is closed once the session is closed.
+func (s *Session) Events() <-chan any {
+	return s.events
+}
+
+func (s *Session) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+}
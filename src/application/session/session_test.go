@@ -0,0 +1,38 @@
+package session
+
+import "testing"
+
+func TestPublish(t *testing.T) {
+	s := NewSession("test")
+
+	s.Publish("event")
+
+	if event := <-s.Events(); event != "event" {
+		t.Errorf("expected <event> but received %v", event)
+	}
+}
+
+func TestPublishFullBuffer(t *testing.T) {
+	s := NewSession("test")
+
+	for i := 0; i <= eventsBufferSize; i++ {
+		s.Publish(i)
+	}
+
+	if len(s.Events()) != eventsBufferSize {
+		t.Errorf("expected %d buffered events but received %d", eventsBufferSize, len(s.Events()))
+	}
+}
+
+func TestPublishAfterClose(t *testing.T) {
+	s := NewSession("test")
+	s.Close()
+
+	// must neither block nor panic
+	s.Publish("event")
+	s.Close()
+
+	if _, open := <-s.Events(); open {
+		t.Errorf("expected the events channel to be closed")
+	}
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"github.com/go-clarum/agent/application/templates"
+	"sync"
+)
+
+// Variables are the values saved by the actions of a session, so that later actions can reference them
+// with placeholders like ${orderId}.
+type Variables struct {
+	values map[string]string
+	lock   sync.RWMutex
+}
+
+func NewVariables() *Variables {
+	return &Variables{
+		values: make(map[string]string),
+	}
+}
+
+func (v *Variables) Set(name string, value string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.values[name] = value
+}
+
+func (v *Variables) Get(name string) (string, bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	value, exists := v.values[name]
+	return value, exists
+}
+
+// Resolve replaces the placeholders in the text with the values of their variables or the results of their
+// template functions. Placeholders of variables that are not set are an error.
+func (v *Variables) Resolve(text string) (string, error) {
+	return templates.Resolve(text, v.Get)
+}
@@ -0,0 +1,35 @@
+package session
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	variables := NewVariables()
+	variables.Set("orderId", "42")
+	variables.Set("token", "abc")
+
+	tests := map[string]string{
+		"":                                 "",
+		"no placeholders":                  "no placeholders",
+		"/orders/${orderId}":               "/orders/42",
+		"${token}${orderId}":               "abc42",
+		`{"id": ${orderId}, "cost": "$5"}`: `{"id": 42, "cost": "$5"}`,
+		"$${orderId} is ${orderId}":        "${orderId} is 42",
+	}
+
+	for text, expected := range tests {
+		if resolved, err := variables.Resolve(text); err != nil || resolved != expected {
+			t.Errorf("expected [%s] for [%s] but received [%s] - %v", expected, text, resolved, err)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	variables := NewVariables()
+
+	if _, err := variables.Resolve("/orders/${orderId}"); err == nil || err.Error() != "variable <orderId> is not set" {
+		t.Errorf("expected unset variable error, but got %v", err)
+	}
+	if _, err := variables.Resolve("/orders/${orderId"); err == nil || err.Error() != "unterminated placeholder [${orderId]" {
+		t.Errorf("expected unterminated placeholder error, but got %v", err)
+	}
+}
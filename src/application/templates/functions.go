@@ -0,0 +1,192 @@
+package templates
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-clarum/agent/application/utils/uuids"
+	"github.com/go-clarum/agent/infrastructure/config"
+	"hash"
+	"math/rand/v2"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+type function struct {
+	minArgs int
+	maxArgs int
+	call    func(args []string) (string, error)
+}
+
+var functions = map[string]function{
+	// uuid() generates a random version 4 UUID
+	"uuid": {0, 0, func(args []string) (string, error) {
+		return uuids.New(), nil
+	}},
+	// randomString(length, [characters]) of alphanumeric characters if none are given
+	"randomString": {1, 2, randomString},
+	// randomNumber(min, max) between min & max, both included
+	"randomNumber": {2, 2, randomNumber},
+	// now([format], [offset]) is the current UTC time, see formatTime for the formats.
+	// The offset is a duration like +1h30m or -15m, days can be given as well, like +2d.
+	"now":          {0, 2, now},
+	"base64":       {1, 1, encoder(base64.StdEncoding.EncodeToString)},
+	"base64Url":    {1, 1, encoder(base64.RawURLEncoding.EncodeToString)},
+	"base64Decode": {1, 1, base64Decode},
+	"urlEncode": {1, 1, func(args []string) (string, error) {
+		return url.QueryEscape(args[0]), nil
+	}},
+	"urlDecode": {1, 1, func(args []string) (string, error) {
+		return url.QueryUnescape(args[0])
+	}},
+	// hashes are returned hex encoded
+	"md5":    {1, 1, hashed(md5.New)},
+	"sha1":   {1, 1, hashed(sha1.New)},
+	"sha256": {1, 1, hashed(sha256.New)},
+	"sha512": {1, 1, hashed(sha512.New)},
+	// file(path) is the content of the file, relative paths are resolved against the base directory of the agent
+	"file": {1, 1, func(args []string) (string, error) {
+		content, err := os.ReadFile(config.ResolvePath(args[0]))
+		if err != nil {
+			return "", err
+		}
+		return string(content), nil
+	}},
+}
+
+func call(name string, args []string) (string, error) {
+	f, exists := functions[name]
+	if !exists {
+		return "", errors.New(fmt.Sprintf("unknown function <%s>", name))
+	}
+	if len(args) < f.minArgs || len(args) > f.maxArgs {
+		return "", errors.New(fmt.Sprintf("function <%s> expects %s arguments but received %d",
+			name, argumentCount(f), len(args)))
+	}
+
+	result, err := f.call(args)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("function <%s> failed - %s", name, err))
+	}
+	return result, nil
+}
+
+func argumentCount(f function) string {
+	if f.minArgs == f.maxArgs {
+		return strconv.Itoa(f.minArgs)
+	}
+	return fmt.Sprintf("%d to %d", f.minArgs, f.maxArgs)
+}
+
+func randomString(args []string) (string, error) {
+	length, err := strconv.Atoi(args[0])
+	if err != nil || length < 0 {
+		return "", errors.New(fmt.Sprintf("invalid length [%s]", args[0]))
+	}
+	characters := []rune(alphanumeric)
+	if len(args) > 1 {
+		characters = []rune(args[1])
+	}
+	if len(characters) == 0 {
+		return "", errors.New("no characters to choose from")
+	}
+
+	result := make([]rune, length)
+	for i := range result {
+		result[i] = characters[rand.IntN(len(characters))]
+	}
+	return string(result), nil
+}
+
+func randomNumber(args []string) (string, error) {
+	minimum, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid minimum [%s]", args[0]))
+	}
+	maximum, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || maximum < minimum {
+		return "", errors.New(fmt.Sprintf("invalid maximum [%s]", args[1]))
+	}
+
+	return strconv.FormatInt(minimum+rand.Int64N(maximum-minimum+1), 10), nil
+}
+
+func now(args []string) (string, error) {
+	current := time.Now().UTC()
+	if len(args) > 1 {
+		offset, err := parseOffset(args[1])
+		if err != nil {
+			return "", err
+		}
+		current = current.Add(offset)
+	}
+
+	format := ""
+	if len(args) > 0 {
+		format = args[0]
+	}
+	return formatTime(current, format), nil
+}
+
+// formatTime supports RFC 3339 by default or with "iso", seconds & milliseconds since the epoch
+// with "unix" & "unixMillis", or any Go layout, like "2006-01-02"
+func formatTime(t time.Time, format string) string {
+	switch format {
+	case "", "iso":
+		return t.Format(time.RFC3339)
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixMillis":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(format)
+	}
+}
+
+func parseOffset(offset string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(offset, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.New(fmt.Sprintf("invalid offset [%s]", offset))
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(offset)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("invalid offset [%s]", offset))
+	}
+	return duration, nil
+}
+
+func encoder(encode func(src []byte) string) func(args []string) (string, error) {
+	return func(args []string) (string, error) {
+		return encode([]byte(args[0])), nil
+	}
+}
+
+func base64Decode(args []string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(args[0])
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+func hashed(newHash func() hash.Hash) func(args []string) (string, error) {
+	return func(args []string) (string, error) {
+		h := newHash()
+		h.Write([]byte(args[0]))
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+}
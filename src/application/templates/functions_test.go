@@ -0,0 +1,91 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRandomValues(t *testing.T) {
+	value, err := call("randomString", []string{"12"})
+	if err != nil || !regexp.MustCompile("^[A-Za-z0-9]{12}$").MatchString(value) {
+		t.Errorf("expected 12 alphanumeric characters but received [%s] - %v", value, err)
+	}
+
+	value, err = call("randomString", []string{"8", "ab"})
+	if err != nil || !regexp.MustCompile("^[ab]{8}$").MatchString(value) {
+		t.Errorf("expected 8 characters out of [ab] but received [%s] - %v", value, err)
+	}
+
+	for i := 0; i < 100; i++ {
+		value, err = call("randomNumber", []string{"-2", "2"})
+		if number, _ := strconv.Atoi(value); err != nil || number < -2 || number > 2 {
+			t.Fatalf("expected a number between -2 and 2 but received [%s] - %v", value, err)
+		}
+	}
+
+	if first, _ := call("uuid", nil); first == "" {
+		t.Errorf("expected a UUID")
+	}
+}
+
+func TestNow(t *testing.T) {
+	before := time.Now().UTC()
+
+	value, err := call("now", []string{})
+	if parsed, parseErr := time.Parse(time.RFC3339, value); err != nil || parseErr != nil || parsed.Before(before.Truncate(time.Second)) {
+		t.Errorf("expected the current time in RFC 3339 but received [%s] - %v", value, err)
+	}
+
+	value, _ = call("now", []string{"2006-01-02", "+2d"})
+	if expected := before.Add(48 * time.Hour).Format("2006-01-02"); value != expected {
+		t.Errorf("expected [%s] but received [%s]", expected, value)
+	}
+
+	value, _ = call("now", []string{"unix", "-1h"})
+	if unix, _ := strconv.ParseInt(value, 10, 64); unix > before.Add(-time.Hour).Unix()+1 || unix < before.Add(-time.Hour).Unix() {
+		t.Errorf("expected the unix time of one hour ago but received [%s]", value)
+	}
+
+	value, _ = call("now", []string{"unixMillis"})
+	if millis, _ := strconv.ParseInt(value, 10, 64); millis < before.UnixMilli() {
+		t.Errorf("expected the current unix time in milliseconds but received [%s]", value)
+	}
+}
+
+func TestEncodingAndHashing(t *testing.T) {
+	tests := []struct {
+		function string
+		arg      string
+		expected string
+	}{
+		{"base64", "a?b>", "YT9iPg=="},
+		{"base64Url", "a?b>", "YT9iPg"},
+		{"base64Decode", "YT9iPg==", "a?b>"},
+		{"urlEncode", "a b&c", "a+b%26c"},
+		{"urlDecode", "a+b%26c", "a b&c"},
+		{"sha1", "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"sha512", "", "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e"},
+	}
+
+	for _, test := range tests {
+		if value, err := call(test.function, []string{test.arg}); err != nil || value != test.expected {
+			t.Errorf("expected %s([%s]) = [%s] but received [%s] - %v", test.function, test.arg, test.expected, value, err)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "order.json")
+	if err := os.WriteFile(file, []byte(`{"id": 42}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := call("file", []string{file}); err != nil || value != `{"id": 42}` {
+		t.Errorf("expected the file content but received [%s] - %v", value, err)
+	}
+}
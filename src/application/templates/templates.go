@@ -0,0 +1,155 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Resolve replaces the placeholders in the text. A placeholder is either a variable, like ${orderId},
+// or a function call, like ${uuid()} or ${now('2006-01-02', +24h)}. Function arguments are literals,
+// quoted or not, or placeholders themselves, like ${sha256(${orderId})}.
+// A placeholder is written literally when escaped as $${orderId}.
+func Resolve(text string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+
+	p := &parser{text: text, lookup: lookup}
+	var result strings.Builder
+	for p.pos < len(text) {
+		start := strings.Index(text[p.pos:], "${")
+		if start < 0 {
+			result.WriteString(text[p.pos:])
+			break
+		}
+		start += p.pos
+
+		if start > p.pos && text[start-1] == '$' {
+			result.WriteString(text[p.pos : start-1])
+			result.WriteString("${")
+			p.pos = start + 2
+			continue
+		}
+
+		result.WriteString(text[p.pos:start])
+		p.pos = start
+		value, err := p.placeholder()
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(value)
+	}
+
+	return result.String(), nil
+}
+
+type parser struct {
+	text   string
+	pos    int
+	lookup func(name string) (string, bool)
+}
+
+// placeholder resolves the placeholder starting at the current position & moves behind it
+func (p *parser) placeholder() (string, error) {
+	start := p.pos
+	p.pos += 2
+
+	end := strings.IndexAny(p.text[p.pos:], "(}")
+	if end < 0 {
+		return "", errors.New(fmt.Sprintf("unterminated placeholder [%s]", p.text[start:]))
+	}
+	end += p.pos
+	name := strings.TrimSpace(p.text[p.pos:end])
+	p.pos = end + 1
+
+	if p.text[end] == '}' {
+		value, exists := p.lookup(name)
+		if !exists {
+			return "", errors.New(fmt.Sprintf("variable <%s> is not set", name))
+		}
+		return value, nil
+	}
+
+	args, err := p.arguments()
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("invalid placeholder [%s] - %s", p.text[start:], err))
+	}
+	p.skipSpaces()
+	if !p.consume('}') {
+		return "", errors.New(fmt.Sprintf("unterminated placeholder [%s]", p.text[start:]))
+	}
+
+	return call(name, args)
+}
+
+// arguments parses the arguments of a function call up to & including the closing parenthesis
+func (p *parser) arguments() ([]string, error) {
+	var args []string
+
+	p.skipSpaces()
+	if p.consume(')') {
+		return args, nil
+	}
+
+	for {
+		arg, err := p.argument()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		p.skipSpaces()
+		if p.consume(')') {
+			return args, nil
+		} else if !p.consume(',') {
+			return nil, errors.New("expected ',' or ')'")
+		}
+		p.skipSpaces()
+	}
+}
+
+func (p *parser) argument() (string, error) {
+	if strings.HasPrefix(p.text[p.pos:], "${") {
+		return p.placeholder()
+	}
+
+	if quote := p.peek(); quote == '\'' || quote == '"' {
+		end := strings.IndexByte(p.text[p.pos+1:], quote)
+		if end < 0 {
+			return "", errors.New("unterminated quote")
+		}
+		arg := p.text[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return arg, nil
+	}
+
+	end := strings.IndexAny(p.text[p.pos:], ",)")
+	if end < 0 {
+		return "", errors.New("expected ',' or ')'")
+	}
+	arg := strings.TrimSpace(p.text[p.pos : p.pos+end])
+	p.pos += end
+	return arg, nil
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpaces() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"testing"
+)
+
+func lookup(values map[string]string) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, exists := values[name]
+		return value, exists
+	}
+}
+
+func TestResolve(t *testing.T) {
+	variables := lookup(map[string]string{"orderId": "42", "token": "a b"})
+
+	tests := map[string]string{
+		"/orders/${orderId}":                    "/orders/42",
+		"${base64('user:secret')}":              "dXNlcjpzZWNyZXQ=",
+		"${ base64 ( user:secret ) }":           "dXNlcjpzZWNyZXQ=",
+		`${urlEncode("a,b)c")}`:                 "a%2Cb%29c",
+		"${urlEncode(${token})}":                "a+b",
+		"${md5(${orderId})}-${orderId}":         "a1d0c6e83f027327d8461063f4ac58a6-42",
+		"${base64Decode(${base64(${token})})}":  "a b",
+		"${randomString(0)}${randomString(0)}x": "x",
+		"$${uuid()} ${randomNumber(7, 7)}":      "${uuid()} 7",
+	}
+
+	for text, expected := range tests {
+		if resolved, err := Resolve(text, variables); err != nil || resolved != expected {
+			t.Errorf("expected [%s] for [%s] but received [%s] - %v", expected, text, resolved, err)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	variables := lookup(map[string]string{})
+
+	tests := map[string]string{
+		"${orderId}":             "variable <orderId> is not set",
+		"${orderId":              "unterminated placeholder [${orderId]",
+		"${unknown()}":           "unknown function <unknown>",
+		"${uuid(1)}":             "function <uuid> expects 0 arguments but received 1",
+		"${now(1, 2, 3)}":        "function <now> expects 0 to 2 arguments but received 3",
+		"${base64('abc)}":        "invalid placeholder [${base64('abc)}] - unterminated quote",
+		"${base64(abc}":          "invalid placeholder [${base64(abc}] - expected ',' or ')'",
+		"${base64(abc) x}":       "unterminated placeholder [${base64(abc) x}]",
+		"${sha256(${orderId})}":  "invalid placeholder [${sha256(${orderId})}] - variable <orderId> is not set",
+		"${randomNumber(5, 1)}":  "function <randomNumber> failed - invalid maximum [1]",
+		"${randomString(-1)}":    "function <randomString> failed - invalid length [-1]",
+		"${now(iso, tomorrow)}":  "function <now> failed - invalid offset [tomorrow]",
+		"${file(/no/such/file)}": "function <file> failed - open /no/such/file: no such file or directory",
+	}
+
+	for text, expected := range tests {
+		if _, err := Resolve(text, variables); err == nil || err.Error() != expected {
+			t.Errorf("expected error [%s] for [%s] but received [%v]", expected, text, err)
+		}
+	}
+}
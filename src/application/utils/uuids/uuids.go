@@ -0,0 +1,17 @@
+package uuids
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// New generates a random (version 4) UUID in its canonical textual representation.
+func New() string {
+	var u [16]byte
+	_, _ = rand.Read(u[:])
+
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,22 @@
+package uuids
+
+import (
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+
+func TestNewFormat(t *testing.T) {
+	result := New()
+
+	if !uuidPattern.MatchString(result) {
+		t.Errorf("expected a version 4 UUID but received %s", result)
+	}
+}
+
+func TestNewUnique(t *testing.T) {
+	if New() == New() {
+		t.Errorf("expected two different UUIDs")
+	}
+}
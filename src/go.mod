@@ -3,15 +3,19 @@ module github.com/go-clarum/agent
 go 1.23.2
 
 require (
+	github.com/PaesslerAG/jsonpath v0.1.1
+	github.com/antchfx/xpath v1.3.5
 	github.com/go-clarum/clarum-json v1.0.0
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+	golang.org/x/net v0.29.0
+	golang.org/x/text v0.18.0
 	google.golang.org/grpc v1.68.0
 	google.golang.org/protobuf v1.35.2
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	golang.org/x/net v0.29.0 // indirect
+	github.com/PaesslerAG/gval v1.0.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/text v0.18.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
 )
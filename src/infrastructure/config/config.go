@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"time"
 )
 
@@ -51,6 +52,18 @@ func BaseDir() string {
 	return *baseDir
 }
 
+// ResolvePath resolves relative paths against the base directory
+func ResolvePath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(*baseDir, file)
+}
+
+func Profile() string {
+	return c.Profile
+}
+
 func LoggingLevel() string {
 	return c.Logging.Level
 }
@@ -27,7 +27,7 @@ func (s *Emitter) Send(msg string) {
 	defer s.mu.RUnlock()
 
 	for listener := range s.listeners {
-		if len(listener) <= channelSize {
+		if len(listener) < channelSize {
 			listener <- msg
 		}
 		// else message is discarded
@@ -25,8 +25,8 @@ func TestSubscribeAndSend(t *testing.T) {
 	wg := sync.WaitGroup{}
 
 	for i := range listeners {
+		wg.Add(1)
 		go func(i int) {
-			wg.Add(1)
 			defer wg.Done()
 
 			select {
@@ -35,7 +35,7 @@ func TestSubscribeAndSend(t *testing.T) {
 					t.Error("message should be received correctly")
 				}
 			case <-time.After(1 * time.Second):
-				t.Fatal("expected message not received")
+				t.Error("expected message not received")
 			}
 		}(i)
 	}
@@ -82,3 +82,26 @@ func TestSubscribeTwice(t *testing.T) {
 		t.Error("channels must be different")
 	}
 }
+
+func TestSendToFullListener(t *testing.T) {
+	emitter := NewEmitter()
+	ch := emitter.Subscribe()
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i <= channelSize; i++ {
+			emitter.Send("message")
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("send must not block on a full listener")
+	}
+
+	if len(ch) != channelSize {
+		t.Errorf("expected %d buffered messages but got %d", channelSize, len(ch))
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"github.com/go-clarum/agent/application/command/cmd/commands"
+	api "github.com/go-clarum/agent/interface/grpc/agent/internal/api/commands/cmd"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func NewInitEndpointCommandFrom(ie *api.InitEndpointCommand) *commands.InitEndpointCommand {
+	return &commands.InitEndpointCommand{
+		Name:                ie.Name,
+		CmdComponents:       ie.CmdComponents,
+		Warmup:              time.Duration(ie.WarmupMillis) * time.Millisecond,
+		ReadinessProbes:     parseReadinessProbes(ie.ReadinessProbes),
+		StreamOutput:        ie.StreamOutput,
+		OutputBufferLines:   int(ie.OutputBufferLines),
+		Env:                 ie.Env,
+		ReplaceEnv:          ie.ReplaceEnv,
+		WorkingDir:          ie.WorkingDir,
+		ShutdownSignal:      commands.ShutdownSignal(ie.ShutdownSignal),
+		ShutdownGracePeriod: time.Duration(ie.ShutdownGracePeriodMillis) * time.Millisecond,
+	}
+}
+
+func NewShutdownEndpointCommandFrom(se *api.ShutdownEndpointCommand) *commands.ShutdownEndpointCommand {
+	return &commands.ShutdownEndpointCommand{
+		Name: se.Name,
+	}
+}
+
+func NewGetOutputCommandFrom(gc *api.GetOutputCommand) *commands.GetOutputCommand {
+	return &commands.GetOutputCommand{
+		Name: gc.Name,
+		Tail: int(gc.Tail),
+	}
+}
+
+func NewAssertRunningCommandFrom(ac *api.AssertRunningCommand) *commands.AssertRunningCommand {
+	return &commands.AssertRunningCommand{
+		Name: ac.Name,
+	}
+}
+
+func NewAssertExitedCommandFrom(ac *api.AssertExitedCommand) *commands.AssertExitedCommand {
+	return &commands.AssertExitedCommand{
+		Name:     ac.Name,
+		ExitCode: int(ac.ExitCode),
+		Timeout:  time.Duration(ac.TimeoutMillis) * time.Millisecond,
+	}
+}
+
+func NewInitEndpointResultFrom(result *commands.InitEndpointResult) *api.InitEndpointResult {
+	return &api.InitEndpointResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewShutdownEndpointResultFrom(result *commands.ShutdownEndpointResult) *api.ShutdownEndpointResult {
+	return &api.ShutdownEndpointResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewGetOutputResultFrom(result *commands.GetOutputResult) *api.GetOutputResult {
+	var lines []*api.OutputLine
+	for _, line := range result.Lines {
+		lines = append(lines, newOutputLineFrom(line))
+	}
+
+	return &api.GetOutputResult{
+		Lines: lines,
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewAssertRunningResultFrom(result *commands.AssertRunningResult) *api.AssertRunningResult {
+	return &api.AssertRunningResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewAssertExitedResultFrom(result *commands.AssertExitedResult) *api.AssertExitedResult {
+	return &api.AssertExitedResult{
+		ExitCode: int32(result.ExitCode),
+		Error:    errorMessage(result.Error),
+	}
+}
+
+func NewExitEventFrom(event *commands.ExitEvent) *api.ExitEvent {
+	return &api.ExitEvent{
+		EndpointName: event.EndpointName,
+		ExitCode:     int32(event.ExitCode),
+		Status:       event.Status,
+	}
+}
+
+func NewOutputEventFrom(event *commands.OutputEvent) *api.OutputEvent {
+	return &api.OutputEvent{
+		EndpointName: event.EndpointName,
+		Line:         newOutputLineFrom(event.Line),
+	}
+}
+
+func newOutputLineFrom(line *commands.OutputLine) *api.OutputLine {
+	return &api.OutputLine{
+		Stream:          api.OutputStream(line.Stream),
+		Line:            line.Line,
+		TimestampMillis: line.Time.UnixMilli(),
+	}
+}
+
+func parseReadinessProbes(apiProbes []*api.ReadinessProbe) []*commands.ReadinessProbe {
+	var result []*commands.ReadinessProbe
+
+	for _, apiProbe := range apiProbes {
+		readinessProbe := &commands.ReadinessProbe{
+			Timeout: time.Duration(apiProbe.TimeoutMillis) * time.Millisecond,
+		}
+
+		switch p := apiProbe.Probe.(type) {
+		case *api.ReadinessProbe_Tcp:
+			readinessProbe.Tcp = &commands.TcpProbe{
+				Address: p.Tcp.Address,
+			}
+		case *api.ReadinessProbe_Http:
+			readinessProbe.Http = &commands.HttpProbe{
+				Url:            p.Http.Url,
+				ExpectedStatus: int(p.Http.ExpectedStatus),
+			}
+		case *api.ReadinessProbe_Output:
+			readinessProbe.Output = &commands.OutputProbe{
+				Pattern: p.Output.Pattern,
+			}
+		}
+
+		result = append(result, readinessProbe)
+	}
+
+	return result
+}
+
+// errors may echo received payloads, but proto strings must be valid UTF-8
+func errorMessage(err error) string {
+	if err != nil {
+		return strings.ToValidUTF8(err.Error(), string(utf8.RuneError))
+	}
+
+	return ""
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/go-clarum/agent/application/command/http/common/model"
 	serverCommands "github.com/go-clarum/agent/application/command/http/server/commands"
 	api "github.com/go-clarum/agent/interface/grpc/agent/internal/api/commands/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 func NewClientInitCommandFrom(is *api.InitClientCommand) *clientCommands.InitEndpointCommand {
@@ -14,63 +16,320 @@ func NewClientInitCommandFrom(is *api.InitClientCommand) *clientCommands.InitEnd
 		BaseUrl:        is.BaseUrl,
 		ContentType:    is.ContentType,
 		TimeoutSeconds: time.Duration(is.TimeoutSeconds) * time.Second,
+		Tls:            parseClientTlsConfig(is.Tls),
+		Http2:          is.Http2,
 	}
 }
 
 func NewClientSendActionFrom(sa *api.ClientSendActionCommand) *clientCommands.SendCommand {
 	return &clientCommands.SendCommand{
-		Name:         sa.Name,
-		Url:          sa.Url,
-		Path:         sa.Path,
-		Method:       sa.Method,
-		QueryParams:  parseQueryParams(sa.QueryParams),
-		Headers:      sa.Headers,
-		Payload:      sa.Payload,
-		EndpointName: sa.EndpointName,
+		Name:                sa.Name,
+		Url:                 sa.Url,
+		Path:                sa.Path,
+		Method:              sa.Method,
+		QueryParams:         parseQueryParams(sa.QueryParams),
+		Headers:             sa.Headers,
+		Payload:             sa.Payload,
+		EndpointName:        sa.EndpointName,
+		Binary:              parseBinaryPayload(sa.Binary),
+		ResolvePlaceholders: sa.ResolvePlaceholders,
 	}
 }
 
 func NewClientReceiveActionFrom(sa *api.ClientReceiveActionCommand) *clientCommands.ReceiveCommand {
 	return &clientCommands.ReceiveCommand{
-		Name:         sa.Name,
-		PayloadType:  model.PayloadType(sa.PayloadType),
-		StatusCode:   int(sa.StatusCode),
-		Headers:      sa.Headers,
-		Payload:      sa.Payload,
-		EndpointName: sa.EndpointName,
+		Name:           sa.Name,
+		PayloadType:    model.PayloadType(sa.PayloadType),
+		StatusCode:     int(sa.StatusCode),
+		Headers:        sa.Headers,
+		Payload:        sa.Payload,
+		EndpointName:   sa.EndpointName,
+		Protocol:       sa.Protocol,
+		Binary:         parseBinaryPayload(sa.Binary),
+		XPath:          sa.Xpath,
+		Namespaces:     sa.Namespaces,
+		JsonPath:       parseJsonPathAssertions(sa.JsonPath),
+		JsonSchema:     sa.JsonSchema,
+		JsonSchemaFile: sa.JsonSchemaFile,
+		Extractions:    parseExtractions(sa.Extractions),
 	}
 }
 
 func NewServerInitRequestFrom(is *api.InitServerCommand) *serverCommands.InitEndpointCommand {
 	return &serverCommands.InitEndpointCommand{
-		Name:           is.Name,
-		Port:           uint(is.Port),
-		TimeoutSeconds: time.Duration(is.TimeoutSeconds) * time.Second,
+		Name:                is.Name,
+		Port:                uint(is.Port),
+		ContentType:         is.ContentType,
+		TimeoutSeconds:      time.Duration(is.TimeoutSeconds) * time.Second,
+		UnmatchedRequests:   serverCommands.UnmatchedRequestPolicy(is.UnmatchedRequestPolicy),
+		UnmatchedStatusCode: int(is.UnmatchedStatusCode),
+		JournalSize:         int(is.JournalSize),
+		Tls:                 parseServerTlsConfig(is.Tls),
+		Http2:               is.Http2,
 	}
 }
 
 func NewServerSendActionFrom(sa *api.ServerSendActionCommand) *serverCommands.SendCommand {
 	return &serverCommands.SendCommand{
-		Name:         sa.Name,
-		StatusCode:   int(sa.StatusCode),
-		Headers:      sa.Headers,
-		Payload:      sa.Payload,
-		EndpointName: sa.EndpointName,
+		Name:                sa.Name,
+		StatusCode:          int(sa.StatusCode),
+		Headers:             sa.Headers,
+		Payload:             sa.Payload,
+		EndpointName:        sa.EndpointName,
+		Matcher:             parseRequestMatcher(sa.Matcher),
+		Behaviour:           parseResponseBehaviour(sa.Behaviour),
+		Binary:              parseBinaryPayload(sa.Binary),
+		ResolvePlaceholders: sa.ResolvePlaceholders,
 	}
 }
 
 func NewServerReceiveActionFrom(ra *api.ServerReceiveActionCommand) *serverCommands.ReceiveCommand {
 	return &serverCommands.ReceiveCommand{
-		Name:         ra.Name,
-		Url:          ra.Url,
-		Path:         ra.Path,
-		Method:       ra.Method,
-		QueryParams:  parseQueryParams(ra.QueryParams),
-		Headers:      ra.Headers,
-		Payload:      ra.Payload,
-		PayloadType:  model.PayloadType(ra.PayloadType),
-		EndpointName: ra.EndpointName,
+		Name:              ra.Name,
+		Url:               ra.Url,
+		Path:              ra.Path,
+		Method:            ra.Method,
+		QueryParams:       parseQueryParams(ra.QueryParams),
+		Headers:           ra.Headers,
+		Payload:           ra.Payload,
+		PayloadType:       model.PayloadType(ra.PayloadType),
+		EndpointName:      ra.EndpointName,
+		Matcher:           parseRequestMatcher(ra.Matcher),
+		Protocol:          ra.Protocol,
+		Binary:            parseBinaryPayload(ra.Binary),
+		XPath:             ra.Xpath,
+		Namespaces:        ra.Namespaces,
+		JsonPath:          parseJsonPathAssertions(ra.JsonPath),
+		JsonSchema:        ra.JsonSchema,
+		JsonSchemaFile:    ra.JsonSchemaFile,
+		Extractions:       parseExtractions(ra.Extractions),
+		SavePathVariables: ra.SavePathVariables,
+	}
+}
+
+func NewAddStubCommandFrom(as *api.AddStubCommand) *serverCommands.AddStubCommand {
+	return &serverCommands.AddStubCommand{
+		Name:         as.Name,
+		EndpointName: as.EndpointName,
+		Matcher:      parseRequestMatcher(as.Matcher),
+		StatusCode:   int(as.StatusCode),
+		Headers:      as.Headers,
+		Payload:      as.Payload,
+		Behaviour:    parseResponseBehaviour(as.Behaviour),
+		Binary:       parseBinaryPayload(as.Binary),
+	}
+}
+
+func NewRemoveStubCommandFrom(rs *api.RemoveStubCommand) *serverCommands.RemoveStubCommand {
+	return &serverCommands.RemoveStubCommand{
+		Name:         rs.Name,
+		EndpointName: rs.EndpointName,
+	}
+}
+
+func NewGetJournalCommandFrom(gj *api.GetJournalCommand) *serverCommands.GetJournalCommand {
+	return &serverCommands.GetJournalCommand{
+		Name:         gj.Name,
+		EndpointName: gj.EndpointName,
+		Matcher:      parseRequestMatcher(gj.Matcher),
+	}
+}
+
+func NewVerifyRequestCountCommandFrom(vc *api.VerifyRequestCountCommand) *serverCommands.VerifyRequestCountCommand {
+	return &serverCommands.VerifyRequestCountCommand{
+		Name:         vc.Name,
+		EndpointName: vc.EndpointName,
+		Matcher:      parseRequestMatcher(vc.Matcher),
+		Count:        int(vc.Count),
+		Timeout:      time.Duration(vc.TimeoutMillis) * time.Millisecond,
+	}
+}
+
+func NewVerifyRequestOrderCommandFrom(vo *api.VerifyRequestOrderCommand) *serverCommands.VerifyRequestOrderCommand {
+	var matchers []*serverCommands.RequestMatcher
+	for _, matcher := range vo.Matchers {
+		matchers = append(matchers, parseRequestMatcher(matcher))
+	}
+
+	return &serverCommands.VerifyRequestOrderCommand{
+		Name:         vo.Name,
+		EndpointName: vo.EndpointName,
+		Matchers:     matchers,
+	}
+}
+
+func NewVerifyNoUnexpectedRequestsCommandFrom(vn *api.VerifyNoUnexpectedRequestsCommand) *serverCommands.VerifyNoUnexpectedRequestsCommand {
+	return &serverCommands.VerifyNoUnexpectedRequestsCommand{
+		Name:         vn.Name,
+		EndpointName: vn.EndpointName,
+	}
+}
+
+func NewClientInitResultFrom(result *clientCommands.InitEndpointResult) *api.InitClientResult {
+	return &api.InitClientResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewClientSendActionResultFrom(result *clientCommands.SendResult) *api.ClientSendActionResult {
+	return &api.ClientSendActionResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewClientReceiveActionResultFrom(result *clientCommands.ReceiveResult) *api.ClientReceiveActionResult {
+	return &api.ClientReceiveActionResult{
+		Error:     errorMessage(result.Error),
+		Response:  newReceivedResponseFrom(result.Response),
+		Variables: newVariablesFrom(result.Variables),
+	}
+}
+
+func NewServerInitResultFrom(result *serverCommands.InitEndpointResult) *api.InitServerResult {
+	return &api.InitServerResult{
+		Error:         errorMessage(result.Error),
+		CaCertificate: result.CaCertificate,
+	}
+}
+
+func NewServerSendActionResultFrom(result *serverCommands.SendResult) *api.ServerSendActionResult {
+	return &api.ServerSendActionResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewServerReceiveActionResultFrom(result *serverCommands.ReceiveResult) *api.ServerReceiveActionResult {
+	return &api.ServerReceiveActionResult{
+		Error:     errorMessage(result.Error),
+		Request:   newReceivedRequestFrom(result.Request),
+		Variables: newVariablesFrom(result.Variables),
+	}
+}
+
+func NewAddStubResultFrom(result *serverCommands.AddStubResult) *api.AddStubResult {
+	return &api.AddStubResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewRemoveStubResultFrom(result *serverCommands.RemoveStubResult) *api.RemoveStubResult {
+	return &api.RemoveStubResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewGetJournalResultFrom(result *serverCommands.GetJournalResult) *api.GetJournalResult {
+	var entries []*api.JournalEntry
+	for _, entry := range result.Entries {
+		entries = append(entries, newJournalEntryFrom(entry))
+	}
+
+	return &api.GetJournalResult{
+		Entries: entries,
+		Error:   errorMessage(result.Error),
+	}
+}
+
+func NewVerifyRequestCountResultFrom(result *serverCommands.VerifyRequestCountResult) *api.VerifyRequestCountResult {
+	return &api.VerifyRequestCountResult{
+		Count: int32(result.Count),
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewVerifyRequestOrderResultFrom(result *serverCommands.VerifyRequestOrderResult) *api.VerifyRequestOrderResult {
+	return &api.VerifyRequestOrderResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func NewVerifyNoUnexpectedRequestsResultFrom(result *serverCommands.VerifyNoUnexpectedRequestsResult) *api.VerifyNoUnexpectedRequestsResult {
+	return &api.VerifyNoUnexpectedRequestsResult{
+		Error: errorMessage(result.Error),
+	}
+}
+
+func newJournalEntryFrom(entry *serverCommands.JournalEntry) *api.JournalEntry {
+	result := &api.JournalEntry{
+		TimeUnixMillis:  entry.Time.UnixMilli(),
+		Method:          entry.Method,
+		Url:             entry.Url,
+		Protocol:        entry.Protocol,
+		Headers:         newStringsListsFrom(entry.Headers),
+		Received:        entry.Received,
+		Stub:            entry.Stub,
+		Completed:       entry.Completed,
+		StatusCode:      int32(entry.StatusCode),
+		ResponseHeaders: newStringsListsFrom(entry.ResponseHeaders),
+	}
+
+	// proto strings must be valid UTF-8, binary payloads are sent as bytes
+	if utf8.ValidString(entry.Payload) {
+		result.Payload = entry.Payload
+	} else {
+		result.PayloadBytes = []byte(entry.Payload)
+	}
+	if utf8.ValidString(entry.ResponsePayload) {
+		result.ResponsePayload = entry.ResponsePayload
+	} else {
+		result.ResponsePayloadBytes = []byte(entry.ResponsePayload)
+	}
+
+	return result
+}
+
+func newReceivedResponseFrom(response *clientCommands.ReceivedResponse) *api.ReceivedResponse {
+	if response == nil {
+		return nil
+	}
+
+	result := &api.ReceivedResponse{
+		StatusCode:       int32(response.StatusCode),
+		Protocol:         response.Protocol,
+		Headers:          newStringsListsFrom(response.Headers),
+		PayloadTruncated: response.PayloadTruncated,
+	}
+	if utf8.ValidString(response.Payload) {
+		result.Payload = response.Payload
+	} else {
+		result.PayloadBytes = []byte(response.Payload)
+	}
+
+	return result
+}
+
+func newReceivedRequestFrom(request *serverCommands.ReceivedRequest) *api.ReceivedRequest {
+	if request == nil {
+		return nil
+	}
+
+	result := &api.ReceivedRequest{
+		Method:           request.Method,
+		Url:              request.Url,
+		Path:             request.Path,
+		QueryParams:      newStringsListsFrom(request.QueryParams),
+		Protocol:         request.Protocol,
+		Headers:          newStringsListsFrom(request.Headers),
+		PathVariables:    newVariablesFrom(request.PathVariables),
+		PayloadTruncated: request.PayloadTruncated,
+	}
+	if utf8.ValidString(request.Payload) {
+		result.Payload = request.Payload
+	} else {
+		result.PayloadBytes = []byte(request.Payload)
+	}
+
+	return result
+}
+
+func newStringsListsFrom(values map[string][]string) map[string]*api.StringsList {
+	result := make(map[string]*api.StringsList)
+
+	for key, value := range values {
+		result[key] = &api.StringsList{Values: value}
 	}
+
+	return result
 }
 
 func parseQueryParams(apiQueryParams map[string]*api.StringsList) map[string][]string {
@@ -84,3 +343,128 @@ func parseQueryParams(apiQueryParams map[string]*api.StringsList) map[string][]s
 
 	return result
 }
+
+func parseRequestMatcher(matcher *api.RequestMatcher) *serverCommands.RequestMatcher {
+	if matcher == nil {
+		return nil
+	}
+
+	return &serverCommands.RequestMatcher{
+		Method:       matcher.Method,
+		Path:         matcher.Path,
+		PathPattern:  matcher.PathPattern,
+		Headers:      matcher.Headers,
+		QueryParams:  parseQueryParams(matcher.QueryParams),
+		BodyContains: matcher.BodyContains,
+		BodyPattern:  matcher.BodyPattern,
+	}
+}
+
+func parseResponseBehaviour(behaviour *api.ResponseBehaviour) *serverCommands.ResponseBehaviour {
+	if behaviour == nil {
+		return nil
+	}
+
+	return &serverCommands.ResponseBehaviour{
+		Delay:      time.Duration(behaviour.DelayMillis) * time.Millisecond,
+		MaxDelay:   time.Duration(behaviour.MaxDelayMillis) * time.Millisecond,
+		ChunkSize:  int(behaviour.ChunkSize),
+		ChunkDelay: time.Duration(behaviour.ChunkDelayMillis) * time.Millisecond,
+		Fault:      serverCommands.Fault(behaviour.Fault),
+	}
+}
+
+func parseBinaryPayload(payload *api.BinaryPayload) *model.BinaryPayload {
+	if payload == nil {
+		return nil
+	}
+
+	return &model.BinaryPayload{
+		Bytes:    payload.Bytes,
+		File:     payload.File,
+		Checksum: payload.Checksum,
+		Size:     payload.Size,
+	}
+}
+
+func parseJsonPathAssertions(assertions []*api.JsonPathAssertion) []model.JsonPathAssertion {
+	var result []model.JsonPathAssertion
+	for _, assertion := range assertions {
+		result = append(result, model.JsonPathAssertion{
+			Path:     assertion.Path,
+			Matcher:  model.JsonPathMatcher(assertion.Matcher),
+			Expected: assertion.Expected,
+		})
+	}
+
+	return result
+}
+
+func parseExtractions(extractions []*api.Extraction) []model.Extraction {
+	var result []model.Extraction
+	for _, extraction := range extractions {
+		result = append(result, model.Extraction{
+			Variable:   extraction.Variable,
+			Source:     model.ExtractionSource(extraction.Source),
+			Expression: extraction.Expression,
+		})
+	}
+
+	return result
+}
+
+func parseClientTlsConfig(config *api.ClientTlsConfig) *clientCommands.TlsConfig {
+	if config == nil {
+		return nil
+	}
+
+	return &clientCommands.TlsConfig{
+		CaFile:                    config.CaFile,
+		TrustTestCa:               config.TrustTestCa,
+		CertFile:                  config.CertFile,
+		KeyFile:                   config.KeyFile,
+		GenerateClientCertificate: config.GenerateClientCertificate,
+		InsecureSkipVerify:        config.InsecureSkipVerify,
+		ServerName:                config.ServerName,
+		MinVersion:                clientCommands.TlsVersion(config.MinVersion),
+	}
+}
+
+func parseServerTlsConfig(config *api.ServerTlsConfig) *serverCommands.TlsConfig {
+	if config == nil {
+		return nil
+	}
+
+	return &serverCommands.TlsConfig{
+		CertFile:                 config.CertFile,
+		KeyFile:                  config.KeyFile,
+		GenerateCertificate:      config.GenerateCertificate,
+		Hosts:                    config.Hosts,
+		RequireClientCertificate: config.RequireClientCertificate,
+		ClientCaFile:             config.ClientCaFile,
+		ExportCaFile:             config.ExportCaFile,
+	}
+}
+
+// variables may be extracted from binary payloads, but proto strings must be valid UTF-8
+func newVariablesFrom(variables map[string]string) map[string]string {
+	if variables == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(variables))
+	for name, value := range variables {
+		result[name] = strings.ToValidUTF8(value, string(utf8.RuneError))
+	}
+
+	return result
+}
+
+// errors may echo received payloads, but proto strings must be valid UTF-8
+func errorMessage(err error) string {
+	if err != nil {
+		return strings.ToValidUTF8(err.Error(), string(utf8.RuneError))
+	}
+
+	return ""
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	"errors"
+	serverCommands "github.com/go-clarum/agent/application/command/http/server/commands"
+	"google.golang.org/protobuf/proto"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestReceiveResultWithBinaryPayloadCanBeMarshalled(t *testing.T) {
+	binary := string([]byte{0x89, 'P', 'N', 'G', 0xff})
+	result := NewServerReceiveActionResultFrom(&serverCommands.ReceiveResult{
+		Error:     errors.New("payload mismatch - expected [text] but received [" + binary + "]"),
+		Request:   &serverCommands.ReceivedRequest{Payload: binary, PathVariables: map[string]string{"id": binary}},
+		Variables: map[string]string{"signature": binary},
+	})
+
+	if _, err := proto.Marshal(result); err != nil {
+		t.Fatalf("no marshal error expected, but got %s", err)
+	}
+	if !utf8.ValidString(result.Error) || result.Error == "" {
+		t.Errorf("expected valid UTF-8 error, but got %q", result.Error)
+	}
+	if string(result.Request.PayloadBytes) != binary {
+		t.Errorf("expected binary payload to be kept as bytes")
+	}
+}
@@ -1,11 +1,176 @@
 package mapper
 
-import "github.com/go-clarum/agent/interface/grpc/agent/internal/api"
+import (
+	"errors"
+	"fmt"
+	cmdCommands "github.com/go-clarum/agent/application/command/cmd/commands"
+	clientCommands "github.com/go-clarum/agent/application/command/http/client/commands"
+	serverCommands "github.com/go-clarum/agent/application/command/http/server/commands"
+	"github.com/go-clarum/agent/application/session"
+	"github.com/go-clarum/agent/interface/grpc/agent/internal/api"
+	cmdMapper "github.com/go-clarum/agent/interface/grpc/agent/internal/mapper/cmd"
+	httpMapper "github.com/go-clarum/agent/interface/grpc/agent/internal/mapper/http"
+	"strings"
+	"unicode/utf8"
+)
 
-func TranslateCommand(command *api.ActionCommand) any {
-	return nil
+// TranslateCommand maps an ActionCommand received from a binding to the command handled by the application layer.
+func TranslateCommand(command *api.ActionCommand) (any, error) {
+	switch action := command.Action.(type) {
+	case *api.ActionCommand_InitSession:
+		return &session.InitCommand{ClientName: action.InitSession.ClientName}, nil
+	case *api.ActionCommand_InitEndpoint:
+		return cmdMapper.NewInitEndpointCommandFrom(action.InitEndpoint), nil
+	case *api.ActionCommand_ShutdownEndpoint:
+		return cmdMapper.NewShutdownEndpointCommandFrom(action.ShutdownEndpoint), nil
+	case *api.ActionCommand_GetOutput:
+		return cmdMapper.NewGetOutputCommandFrom(action.GetOutput), nil
+	case *api.ActionCommand_AssertRunning:
+		return cmdMapper.NewAssertRunningCommandFrom(action.AssertRunning), nil
+	case *api.ActionCommand_AssertExited:
+		return cmdMapper.NewAssertExitedCommandFrom(action.AssertExited), nil
+	case *api.ActionCommand_InitClient:
+		return httpMapper.NewClientInitCommandFrom(action.InitClient), nil
+	case *api.ActionCommand_InitServer:
+		return httpMapper.NewServerInitRequestFrom(action.InitServer), nil
+	case *api.ActionCommand_ClientSendAction:
+		return httpMapper.NewClientSendActionFrom(action.ClientSendAction), nil
+	case *api.ActionCommand_ClientReceiveAction:
+		return httpMapper.NewClientReceiveActionFrom(action.ClientReceiveAction), nil
+	case *api.ActionCommand_ServerSendAction:
+		return httpMapper.NewServerSendActionFrom(action.ServerSendAction), nil
+	case *api.ActionCommand_ServerReceiveAction:
+		return httpMapper.NewServerReceiveActionFrom(action.ServerReceiveAction), nil
+	case *api.ActionCommand_AddStub:
+		return httpMapper.NewAddStubCommandFrom(action.AddStub), nil
+	case *api.ActionCommand_RemoveStub:
+		return httpMapper.NewRemoveStubCommandFrom(action.RemoveStub), nil
+	case *api.ActionCommand_GetJournal:
+		return httpMapper.NewGetJournalCommandFrom(action.GetJournal), nil
+	case *api.ActionCommand_VerifyRequestCount:
+		return httpMapper.NewVerifyRequestCountCommandFrom(action.VerifyRequestCount), nil
+	case *api.ActionCommand_VerifyRequestOrder:
+		return httpMapper.NewVerifyRequestOrderCommandFrom(action.VerifyRequestOrder), nil
+	case *api.ActionCommand_VerifyNoUnexpectedRequests:
+		return httpMapper.NewVerifyNoUnexpectedRequestsCommandFrom(action.VerifyNoUnexpectedRequests), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported command [%T]", action))
+	}
 }
 
+// TranslateResult maps the result of an executed command to the CommandResponse sent back to the binding.
 func TranslateResult(result any) *api.CommandResponse {
-	return nil
+	switch r := result.(type) {
+	case *session.InitResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_InitSessionResult{
+			InitSessionResult: &api.InitSessionResult{
+				SessionId: r.SessionId,
+				Error:     errorMessage(r.Error),
+			},
+		}}
+	case *cmdCommands.InitEndpointResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_InitEndpointResult{
+			InitEndpointResult: cmdMapper.NewInitEndpointResultFrom(r),
+		}}
+	case *cmdCommands.ShutdownEndpointResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_ShutdownEndpointResult{
+			ShutdownEndpointResult: cmdMapper.NewShutdownEndpointResultFrom(r),
+		}}
+	case *cmdCommands.GetOutputResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_GetOutputResult{
+			GetOutputResult: cmdMapper.NewGetOutputResultFrom(r),
+		}}
+	case *cmdCommands.AssertRunningResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_AssertRunningResult{
+			AssertRunningResult: cmdMapper.NewAssertRunningResultFrom(r),
+		}}
+	case *cmdCommands.AssertExitedResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_AssertExitedResult{
+			AssertExitedResult: cmdMapper.NewAssertExitedResultFrom(r),
+		}}
+	case *clientCommands.InitEndpointResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_InitClientResult{
+			InitClientResult: httpMapper.NewClientInitResultFrom(r),
+		}}
+	case *clientCommands.SendResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_ClientSendActionResult{
+			ClientSendActionResult: httpMapper.NewClientSendActionResultFrom(r),
+		}}
+	case *clientCommands.ReceiveResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_ClientReceiveActionResult{
+			ClientReceiveActionResult: httpMapper.NewClientReceiveActionResultFrom(r),
+		}}
+	case *serverCommands.InitEndpointResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_InitServerResult{
+			InitServerResult: httpMapper.NewServerInitResultFrom(r),
+		}}
+	case *serverCommands.SendResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_ServerSendActionResult{
+			ServerSendActionResult: httpMapper.NewServerSendActionResultFrom(r),
+		}}
+	case *serverCommands.ReceiveResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_ServerReceiveActionResult{
+			ServerReceiveActionResult: httpMapper.NewServerReceiveActionResultFrom(r),
+		}}
+	case *serverCommands.AddStubResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_AddStubResult{
+			AddStubResult: httpMapper.NewAddStubResultFrom(r),
+		}}
+	case *serverCommands.RemoveStubResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_RemoveStubResult{
+			RemoveStubResult: httpMapper.NewRemoveStubResultFrom(r),
+		}}
+	case *serverCommands.GetJournalResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_GetJournalResult{
+			GetJournalResult: httpMapper.NewGetJournalResultFrom(r),
+		}}
+	case *serverCommands.VerifyRequestCountResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_VerifyRequestCountResult{
+			VerifyRequestCountResult: httpMapper.NewVerifyRequestCountResultFrom(r),
+		}}
+	case *serverCommands.VerifyRequestOrderResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_VerifyRequestOrderResult{
+			VerifyRequestOrderResult: httpMapper.NewVerifyRequestOrderResultFrom(r),
+		}}
+	case *serverCommands.VerifyNoUnexpectedRequestsResult:
+		return &api.CommandResponse{Result: &api.CommandResponse_VerifyNoUnexpectedRequestsResult{
+			VerifyNoUnexpectedRequestsResult: httpMapper.NewVerifyNoUnexpectedRequestsResultFrom(r),
+		}}
+	default:
+		return TranslateError(errors.New(fmt.Sprintf("unsupported result [%T]", result)))
+	}
+}
+
+// TranslateEvent maps an event published to the session to the CommandResponse sent to the binding.
+func TranslateEvent(event any) (*api.CommandResponse, error) {
+	switch e := event.(type) {
+	case *cmdCommands.OutputEvent:
+		return &api.CommandResponse{Result: &api.CommandResponse_OutputEvent{
+			OutputEvent: cmdMapper.NewOutputEventFrom(e),
+		}}, nil
+	case *cmdCommands.ExitEvent:
+		return &api.CommandResponse{Result: &api.CommandResponse_ExitEvent{
+			ExitEvent: cmdMapper.NewExitEventFrom(e),
+		}}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unsupported event [%T]", event))
+	}
+}
+
+// TranslateError creates the response for a command that could not be executed at all.
+func TranslateError(err error) *api.CommandResponse {
+	return &api.CommandResponse{Result: &api.CommandResponse_ErrorResult{
+		ErrorResult: &api.ErrorResult{
+			Error: errorMessage(err),
+		},
+	}}
+}
+
+// errors may echo received payloads, but proto strings must be valid UTF-8
+func errorMessage(err error) string {
+	if err != nil {
+		return strings.ToValidUTF8(err.Error(), string(utf8.RuneError))
+	}
+
+	return ""
 }
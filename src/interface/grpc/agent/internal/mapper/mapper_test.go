@@ -0,0 +1,122 @@
+package mapper
+
+import (
+	"errors"
+	cmdCommands "github.com/go-clarum/agent/application/command/cmd/commands"
+	serverCommands "github.com/go-clarum/agent/application/command/http/server/commands"
+	"github.com/go-clarum/agent/application/session"
+	"github.com/go-clarum/agent/interface/grpc/agent/internal/api"
+	cmdApi "github.com/go-clarum/agent/interface/grpc/agent/internal/api/commands/cmd"
+	httpApi "github.com/go-clarum/agent/interface/grpc/agent/internal/api/commands/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranslateCommand(t *testing.T) {
+	command, err := TranslateCommand(&api.ActionCommand{Action: &api.ActionCommand_InitSession{
+		InitSession: &api.InitSessionCommand{ClientName: "binding"}}})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	if initCommand, ok := command.(*session.InitCommand); !ok || initCommand.ClientName != "binding" {
+		t.Errorf("unexpected command %#v", command)
+	}
+
+	command, err = TranslateCommand(&api.ActionCommand{Action: &api.ActionCommand_AssertExited{
+		AssertExited: &cmdApi.AssertExitedCommand{Name: "sut", ExitCode: 3, TimeoutMillis: 500}}})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	assertExited, ok := command.(*cmdCommands.AssertExitedCommand)
+	if !ok || assertExited.Name != "sut" || assertExited.ExitCode != 3 || assertExited.Timeout != 500*time.Millisecond {
+		t.Errorf("unexpected command %#v", command)
+	}
+
+	command, err = TranslateCommand(&api.ActionCommand{Action: &api.ActionCommand_VerifyRequestCount{
+		VerifyRequestCount: &httpApi.VerifyRequestCountCommand{Name: "verify", EndpointName: "server", Count: 2}}})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	verifyCount, ok := command.(*serverCommands.VerifyRequestCountCommand)
+	if !ok || verifyCount.EndpointName != "server" || verifyCount.Count != 2 {
+		t.Errorf("unexpected command %#v", command)
+	}
+}
+
+func TestTranslateUnsupportedCommand(t *testing.T) {
+	command, err := TranslateCommand(&api.ActionCommand{})
+
+	if err == nil || !strings.Contains(err.Error(), "unsupported command") {
+		t.Errorf("expected unsupported command error, but got %v", err)
+	}
+	if command != nil {
+		t.Errorf("no command expected, but got %#v", command)
+	}
+}
+
+func TestTranslateResult(t *testing.T) {
+	response := TranslateResult(&session.InitResult{SessionId: "id"})
+	if result := response.GetInitSessionResult(); result == nil || result.SessionId != "id" || result.Error != "" {
+		t.Errorf("unexpected response %v", response)
+	}
+
+	response = TranslateResult(&cmdCommands.AssertExitedResult{ExitCode: 1, Error: errors.New("exit code mismatch")})
+	if result := response.GetAssertExitedResult(); result == nil || result.ExitCode != 1 || result.Error != "exit code mismatch" {
+		t.Errorf("unexpected response %v", response)
+	}
+
+	response = TranslateResult(&serverCommands.VerifyRequestCountResult{})
+	if result := response.GetVerifyRequestCountResult(); result == nil || result.Error != "" {
+		t.Errorf("unexpected response %v", response)
+	}
+}
+
+func TestTranslateUnsupportedResult(t *testing.T) {
+	response := TranslateResult("result")
+
+	if result := response.GetErrorResult(); result == nil || !strings.Contains(result.Error, "unsupported result [string]") {
+		t.Errorf("expected error result, but got %v", response)
+	}
+}
+
+func TestTranslateEvent(t *testing.T) {
+	response, err := TranslateEvent(&cmdCommands.OutputEvent{EndpointName: "sut",
+		Line: &cmdCommands.OutputLine{Stream: cmdCommands.Stderr, Line: "failed", Time: time.UnixMilli(1000)}})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	outputEvent := response.GetOutputEvent()
+	if outputEvent == nil || outputEvent.EndpointName != "sut" || outputEvent.Line.Line != "failed" ||
+		outputEvent.Line.Stream != cmdApi.OutputStream_Stderr || outputEvent.Line.TimestampMillis != 1000 {
+		t.Errorf("unexpected response %v", response)
+	}
+
+	response, err = TranslateEvent(&cmdCommands.ExitEvent{EndpointName: "sut", ExitCode: 2, Status: "exit status 2"})
+	if err != nil {
+		t.Fatalf("no error expected, but got %s", err)
+	}
+	exitEvent := response.GetExitEvent()
+	if exitEvent == nil || exitEvent.EndpointName != "sut" || exitEvent.ExitCode != 2 || exitEvent.Status != "exit status 2" {
+		t.Errorf("unexpected response %v", response)
+	}
+}
+
+func TestTranslateUnsupportedEvent(t *testing.T) {
+	response, err := TranslateEvent("event")
+
+	if err == nil || !strings.Contains(err.Error(), "unsupported event [string]") {
+		t.Errorf("expected unsupported event error, but got %v", err)
+	}
+	if response != nil {
+		t.Errorf("no response expected, but got %v", response)
+	}
+}
+
+func TestTranslateErrorWithInvalidUTF8(t *testing.T) {
+	response := TranslateError(errors.New("received [\xff]"))
+
+	if result := response.GetErrorResult(); result.Error != "received [�]" {
+		t.Errorf("expected invalid UTF-8 to be replaced, but got %q", result.Error)
+	}
+}
@@ -4,15 +4,16 @@ import (
 	"context"
 	"github.com/go-clarum/agent/application/command"
 	"github.com/go-clarum/agent/application/services/agent"
+	"github.com/go-clarum/agent/application/services/session"
 	"github.com/go-clarum/agent/infrastructure/config"
 	"github.com/go-clarum/agent/infrastructure/logging"
 	"github.com/go-clarum/agent/interface/grpc/agent/internal/api"
-	"github.com/go-clarum/agent/interface/grpc/agent/internal/mapper"
 	"google.golang.org/grpc"
-	"io"
 )
 
-var agentService = agent.NewAgentService()
+var sessionService = session.NewSessionService()
+var agentService = agent.NewAgentService(sessionService)
+var mediator = command.GetMediator()
 
 type grpcService struct {
 	api.UnimplementedAgentApiServer
@@ -38,27 +39,11 @@ func (s *grpcService) Shutdown(ctx context.Context, request *api.ShutdownRequest
 	return &api.ShutdownResponse{}, nil
 }
 
+// Session executes the commands of one binding. The first command must be initSession,
+// all endpoints created afterwards belong to that session and are shut down once the stream is closed.
 func (s *grpcService) Session(stream grpc.BidiStreamingServer[api.ActionCommand, api.CommandResponse]) error {
-	logging.Debug("session started")
+	logging.Debug("session stream opened")
+	defer logging.Debug("session stream closed")
 
-	for {
-		in, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		var inCommand = mapper.TranslateCommand(in)
-		result := command.GetMediator().DelegateCommand(inCommand)
-		var outResult = mapper.TranslateResult(result)
-
-		if err := stream.Send(outResult); err != nil {
-			return err
-		}
-	}
-
-	logging.Debug("session closed")
-	return nil
+	return newSessionStream(stream).run()
 }
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	domain "github.com/go-clarum/agent/application/session"
+	clarumstrings "github.com/go-clarum/agent/application/validators/strings"
+	"github.com/go-clarum/agent/infrastructure/logging"
+	"github.com/go-clarum/agent/interface/grpc/agent/internal/api"
+	"github.com/go-clarum/agent/interface/grpc/agent/internal/mapper"
+	"google.golang.org/grpc"
+	"io"
+	"sync"
+)
+
+// sessionStream executes the commands received over one Session stream.
+// Commands without a correlation id are executed in the order they are received.
+// Commands with a correlation id are executed concurrently, their results are sent
+// back as soon as they are available, so they may arrive out of order.
+type sessionStream struct {
+	stream     grpc.BidiStreamingServer[api.ActionCommand, api.CommandResponse]
+	session    *domain.Session
+	sendLock   sync.Mutex
+	running    sync.WaitGroup
+	forwarding sync.WaitGroup
+}
+
+func newSessionStream(stream grpc.BidiStreamingServer[api.ActionCommand, api.CommandResponse]) *sessionStream {
+	return &sessionStream{
+		stream: stream,
+	}
+}
+
+func (s *sessionStream) run() error {
+	for {
+		in, err := s.stream.Recv()
+		if err == io.EOF {
+			// the binding has stopped sending, but still waits for the results of running actions
+			s.running.Wait()
+			s.closeSession()
+			return nil
+		}
+		if err != nil {
+			// the stream is gone, so we shut down the endpoints first in order to release running actions
+			s.closeSession()
+			s.running.Wait()
+			return err
+		}
+
+		if err := s.dispatch(in); err != nil {
+			s.closeSession()
+			s.running.Wait()
+			return err
+		}
+	}
+}
+
+func (s *sessionStream) dispatch(in *api.ActionCommand) error {
+	inCommand, err := mapper.TranslateCommand(in)
+	if err != nil {
+		return s.send(in.CorrelationId, errorResponse("unable to translate command - %s", err))
+	}
+
+	if initCommand, ok := inCommand.(*domain.InitCommand); ok {
+		return s.send(in.CorrelationId, mapper.TranslateResult(s.initSession(initCommand)))
+	}
+
+	if s.session == nil {
+		return s.send(in.CorrelationId,
+			errorResponse("session not initialized - command [%T] will not be executed", inCommand))
+	}
+
+	if clarumstrings.IsBlank(in.CorrelationId) {
+		return s.send(in.CorrelationId, s.execute(inCommand))
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+
+		if err := s.send(in.CorrelationId, s.execute(inCommand)); err != nil {
+			logging.Errorf("unable to send result of command [%s] - %s", in.CorrelationId, err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *sessionStream) execute(inCommand any) *api.CommandResponse {
+	result, err := mediator.DelegateCommand(s.session, inCommand)
+	if err != nil {
+		return mapper.TranslateError(err)
+	}
+
+	return mapper.TranslateResult(result)
+}
+
+func (s *sessionStream) initSession(command *domain.InitCommand) *domain.InitResult {
+	if s.session != nil {
+		return &domain.InitResult{
+			SessionId: s.session.Id,
+			Error:     errors.New(fmt.Sprintf("session [%s] is already initialized", s.session.Id)),
+		}
+	}
+
+	s.session = sessionService.Open(command.ClientName)
+
+	s.forwarding.Add(1)
+	go s.forwardEvents()
+
+	return &domain.InitResult{SessionId: s.session.Id}
+}
+
+// forwardEvents sends the events published to the session until the session is closed
+func (s *sessionStream) forwardEvents() {
+	defer s.forwarding.Done()
+
+	for event := range s.session.Events() {
+		outEvent, err := mapper.TranslateEvent(event)
+		if err != nil {
+			logging.Errorf("unable to translate event - %s", err)
+			continue
+		}
+
+		if err := s.send("", outEvent); err != nil {
+			logging.Errorf("unable to send event - %s", err)
+		}
+	}
+}
+
+// grpc streams do not support concurrent sends
+func (s *sessionStream) send(correlationId string, response *api.CommandResponse) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	response.CorrelationId = correlationId
+	return s.stream.Send(response)
+}
+
+// the events published while the endpoints shut down are still sent, before the stream ends
+func (s *sessionStream) closeSession() {
+	if s.session != nil {
+		sessionService.Close(s.session.Id)
+		s.forwarding.Wait()
+	}
+}
+
+func errorResponse(format string, a ...any) *api.CommandResponse {
+	errorMessage := fmt.Sprintf(format, a...)
+	logging.Error(errorMessage)
+	return mapper.TranslateError(errors.New(errorMessage))
+}
@@ -0,0 +1,296 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"github.com/go-clarum/agent/interface/grpc/agent/internal/api"
+	cmdApi "github.com/go-clarum/agent/interface/grpc/agent/internal/api/commands/cmd"
+	"google.golang.org/grpc"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStream is the Session stream of a binding, the commands are received until in is closed
+type fakeStream struct {
+	grpc.ServerStream
+	in      chan *api.ActionCommand
+	recvErr error
+	out     chan *api.CommandResponse
+	lock    sync.Mutex
+	sendErr error
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{
+		in:      make(chan *api.ActionCommand, 10),
+		recvErr: io.EOF,
+		out:     make(chan *api.CommandResponse, 100),
+	}
+}
+
+func (f *fakeStream) Recv() (*api.ActionCommand, error) {
+	if command, ok := <-f.in; ok {
+		return command, nil
+	}
+	return nil, f.recvErr
+}
+
+func (f *fakeStream) Send(response *api.CommandResponse) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.sendErr != nil {
+		return f.sendErr
+	}
+	f.out <- response
+	return nil
+}
+
+func (f *fakeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (f *fakeStream) failSends(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.sendErr = err
+}
+
+// next returns the next response sent to the binding
+func (f *fakeStream) next(t *testing.T) *api.CommandResponse {
+	t.Helper()
+
+	select {
+	case response := <-f.out:
+		return response
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response sent")
+		return nil
+	}
+}
+
+// nextResult returns the next response sent to the binding that is not an event
+func (f *fakeStream) nextResult(t *testing.T) *api.CommandResponse {
+	t.Helper()
+
+	for {
+		if response := f.next(t); !isEvent(response) {
+			return response
+		}
+	}
+}
+
+func isEvent(response *api.CommandResponse) bool {
+	return response.GetOutputEvent() != nil || response.GetExitEvent() != nil
+}
+
+// runSession runs the session stream in the background, the returned channel receives the result of run
+func runSession(stream *fakeStream) (*sessionStream, chan error) {
+	s := newSessionStream(stream)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.run()
+	}()
+
+	return s, done
+}
+
+func awaitEnd(t *testing.T, done chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("session stream did not end")
+		return nil
+	}
+}
+
+func initSession(t *testing.T, stream *fakeStream) string {
+	t.Helper()
+
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_InitSession{
+		InitSession: &api.InitSessionCommand{ClientName: "test"}}}
+	result := stream.nextResult(t).GetInitSessionResult()
+	if result == nil || result.Error != "" || result.SessionId == "" {
+		t.Fatalf("expected the session to be initialized, but got %v", result)
+	}
+
+	return result.SessionId
+}
+
+func initEndpoint(t *testing.T, stream *fakeStream, name string, cmd string, streamOutput bool) {
+	t.Helper()
+
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_InitEndpoint{InitEndpoint: &cmdApi.InitEndpointCommand{
+		Name: name, CmdComponents: []string{"sh", "-c", cmd}, StreamOutput: streamOutput}}}
+	if result := stream.nextResult(t).GetInitEndpointResult(); result == nil || result.Error != "" {
+		t.Fatalf("expected the endpoint to be initialized, but got %v", result)
+	}
+}
+
+func assertExited(correlationId string, name string) *api.ActionCommand {
+	return &api.ActionCommand{CorrelationId: correlationId, Action: &api.ActionCommand_AssertExited{
+		AssertExited: &cmdApi.AssertExitedCommand{Name: name, TimeoutMillis: 5000}}}
+}
+
+func assertSessionClosed(t *testing.T, s *sessionStream) {
+	t.Helper()
+
+	for range s.session.Events() {
+		// the events published before the session was closed
+	}
+}
+
+func TestCommandBeforeInitIsRejected(t *testing.T) {
+	stream := newFakeStream()
+	_, done := runSession(stream)
+
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_AssertRunning{
+		AssertRunning: &cmdApi.AssertRunningCommand{Name: "sut"}}}
+	result := stream.nextResult(t).GetErrorResult()
+	if result == nil || !strings.Contains(result.Error, "session not initialized") {
+		t.Errorf("expected the command to be rejected, but got %v", result)
+	}
+
+	close(stream.in)
+	if err := awaitEnd(t, done); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+}
+
+func TestRepeatedInitIsRejected(t *testing.T) {
+	stream := newFakeStream()
+	_, done := runSession(stream)
+	sessionId := initSession(t, stream)
+
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_InitSession{
+		InitSession: &api.InitSessionCommand{ClientName: "test"}}}
+	result := stream.nextResult(t).GetInitSessionResult()
+	if result == nil || result.SessionId != sessionId || !strings.Contains(result.Error, "already initialized") {
+		t.Errorf("expected the second init to be rejected, but got %v", result)
+	}
+
+	close(stream.in)
+	_ = awaitEnd(t, done)
+}
+
+func TestUnsupportedCommandIsRejected(t *testing.T) {
+	stream := newFakeStream()
+	_, done := runSession(stream)
+
+	stream.in <- &api.ActionCommand{CorrelationId: "empty"}
+	response := stream.nextResult(t)
+	if response.CorrelationId != "empty" || !strings.Contains(response.GetErrorResult().GetError(), "unable to translate command") {
+		t.Errorf("expected the command to be rejected, but got %v", response)
+	}
+
+	close(stream.in)
+	_ = awaitEnd(t, done)
+}
+
+func TestCorrelatedResultsAreSentWhenAvailable(t *testing.T) {
+	stream := newFakeStream()
+	_, done := runSession(stream)
+	initSession(t, stream)
+	initEndpoint(t, stream, "sut", "sleep 0.3", false)
+
+	// the first command blocks until the process exits, the second one is answered right away
+	stream.in <- assertExited("slow", "sut")
+	stream.in <- &api.ActionCommand{CorrelationId: "fast", Action: &api.ActionCommand_AssertRunning{
+		AssertRunning: &cmdApi.AssertRunningCommand{Name: "sut"}}}
+
+	if first := stream.nextResult(t); first.CorrelationId != "fast" || first.GetAssertRunningResult() == nil {
+		t.Errorf("expected the result of the fast command first, but got %v", first)
+	}
+	if second := stream.nextResult(t); second.CorrelationId != "slow" || second.GetAssertExitedResult().GetError() != "" {
+		t.Errorf("expected the result of the slow command second, but got %v", second)
+	}
+
+	close(stream.in)
+	_ = awaitEnd(t, done)
+}
+
+func TestEndOfStreamWaitsForRunningActions(t *testing.T) {
+	stream := newFakeStream()
+	s, done := runSession(stream)
+	initSession(t, stream)
+	initEndpoint(t, stream, "sut", "sleep 0.2", false)
+
+	stream.in <- assertExited("exited", "sut")
+	close(stream.in)
+
+	if err := awaitEnd(t, done); err != nil {
+		t.Errorf("no error expected, but got %s", err)
+	}
+	close(stream.out)
+	var result *api.CommandResponse
+	for response := range stream.out {
+		if !isEvent(response) {
+			result = response
+		}
+	}
+	if result.GetCorrelationId() != "exited" || result.GetAssertExitedResult() == nil {
+		t.Errorf("expected the result of the running action to be sent before the stream ended, but got %v", result)
+	}
+	assertSessionClosed(t, s)
+}
+
+func TestEventsAreForwarded(t *testing.T) {
+	stream := newFakeStream()
+	_, done := runSession(stream)
+	initSession(t, stream)
+
+	// the output may be forwarded before the endpoint is reported as initialized
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_InitEndpoint{InitEndpoint: &cmdApi.InitEndpointCommand{
+		Name: "sut", CmdComponents: []string{"sh", "-c", "echo started; sleep 30"}, StreamOutput: true}}}
+	var event *cmdApi.OutputEvent
+	for event == nil {
+		response := stream.next(t)
+		if response.GetOutputEvent() != nil && response.CorrelationId == "" {
+			event = response.GetOutputEvent()
+		}
+	}
+	if event.GetEndpointName() != "sut" || event.GetLine().GetLine() != "started" {
+		t.Errorf("unexpected output event %v", event)
+	}
+
+	close(stream.in)
+	_ = awaitEnd(t, done)
+}
+
+func TestStreamErrorClosesSession(t *testing.T) {
+	stream := newFakeStream()
+	stream.recvErr = errors.New("connection lost")
+	s, done := runSession(stream)
+	initSession(t, stream)
+	initEndpoint(t, stream, "sut", "sleep 30", false)
+
+	// the running action is released once the session shut down its endpoints
+	stream.in <- assertExited("exited", "sut")
+	close(stream.in)
+
+	if err := awaitEnd(t, done); err == nil || err.Error() != "connection lost" {
+		t.Errorf("expected the stream error, but got %v", err)
+	}
+	assertSessionClosed(t, s)
+}
+
+func TestSendErrorClosesSession(t *testing.T) {
+	stream := newFakeStream()
+	s, done := runSession(stream)
+	initSession(t, stream)
+
+	stream.failSends(errors.New("connection lost"))
+	stream.in <- &api.ActionCommand{Action: &api.ActionCommand_AssertRunning{
+		AssertRunning: &cmdApi.AssertRunningCommand{Name: "sut"}}}
+
+	if err := awaitEnd(t, done); err == nil || err.Error() != "connection lost" {
+		t.Errorf("expected the send error, but got %v", err)
+	}
+	assertSessionClosed(t, s)
+}